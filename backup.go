@@ -0,0 +1,498 @@
+package jsonbank
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/jsonbankio/go-sdk/types"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Archive formats of Backup
+const (
+	ArchiveTarGz = "tar.gz"
+	ArchiveZip   = "zip"
+)
+
+// Conflict policies of Restore, for documents that already exist in the target project
+const (
+	ConflictSkip      = "skip"      // keep the existing document
+	ConflictOverwrite = "overwrite" // replace the content of the existing document
+	ConflictFail      = "fail"      // restore nothing when any document exists
+)
+
+// backupManifest - name of the manifest in a backup archive, documents are stored below backupDocuments
+const (
+	backupManifest  = "manifest.json"
+	backupDocuments = "documents/"
+)
+
+// BackupOptions - options of BackupWithOptions
+type BackupOptions struct {
+	Format      string // ArchiveTarGz by default
+	Concurrency int    // Maximum number of concurrent requests, 4 by default
+}
+
+// RestoreOptions - options of Restore
+type RestoreOptions struct {
+	Conflict     string // ConflictSkip by default
+	Concurrency  int    // Maximum number of concurrent requests, 4 by default
+	MaxFileSize  int64  // Maximum uncompressed size of a file of the archive, 64 MB by default
+	MaxTotalSize int64  // Maximum uncompressed size of all files of the archive, 1 GB by default
+}
+
+// RestoreReport - changes made by Restore, paths are relative to the target project
+type RestoreReport struct {
+	CreatedFolders []string
+	Created        []string
+	Overwritten    []string
+	Skipped        []string
+	Errors         []SyncError
+}
+
+// Backup - writes a tar.gz archive of every document of a project and a manifest of their metadata and folders
+func (jsb *Instance) Backup(project string, w io.Writer) (*types.Manifest, *RequestError) {
+	return jsb.BackupWithOptions(project, w, BackupOptions{})
+}
+
+// BackupWithOptions - writes a tar.gz or zip archive of a project
+// documents are downloaded concurrently and streamed in path order, the manifest is written last with their content hashes
+func (jsb *Instance) BackupWithOptions(project string, w io.Writer, options BackupOptions) (*types.Manifest, *RequestError) {
+	if project == "" {
+		return nil, &RequestError{"bad_request", "Project is required"}
+	}
+
+	if options.Format == "" {
+		options.Format = ArchiveTarGz
+	}
+
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+
+	var archive archiveWriter
+	switch options.Format {
+	case ArchiveTarGz:
+		archive = newTarGzWriter(w)
+	case ArchiveZip:
+		archive = &zipArchiveWriter{zip.NewWriter(w)}
+	default:
+		return nil, &RequestError{"unsupported_format", "Unsupported archive format " + options.Format}
+	}
+
+	manifest, err := jsb.projectManifest(project, options.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	// download ahead of the writer, results are consumed in order
+	type download struct {
+		content string
+		err     *RequestError
+	}
+
+	slots := make(chan struct{}, options.Concurrency)
+	downloads := make([]chan download, len(manifest.Documents))
+	for i := range manifest.Documents {
+		downloads[i] = make(chan download, 1)
+	}
+
+	// no more downloads are started once one failed or the backup returned
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	cancel := func() { stopOnce.Do(func() { close(stop) }) }
+	defer cancel()
+
+	go func() {
+		for i := range manifest.Documents {
+			select {
+			case slots <- struct{}{}:
+			case <-stop:
+				return
+			}
+
+			go func(i int) {
+				defer func() { <-slots }()
+				content, err := jsb.GetOwnContentAsString(manifest.Documents[i].Id)
+				if err != nil {
+					cancel()
+				}
+				downloads[i] <- download{content, err}
+			}(i)
+		}
+	}()
+
+	for i := range manifest.Documents {
+		result := <-downloads[i]
+		if result.err != nil {
+			return nil, result.err
+		}
+
+		document := &manifest.Documents[i]
+		document.ContentHash = ContentHash(result.content)
+		if writeErr := archive.add(backupDocuments+document.Path, []byte(result.content), parseTime(document.UpdatedAt)); writeErr != nil {
+			return nil, &RequestError{"backup_error", writeErr.Error()}
+		}
+	}
+
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	if writeErr := archive.add(backupManifest, data, time.Now()); writeErr != nil {
+		return nil, &RequestError{"backup_error", writeErr.Error()}
+	}
+
+	if closeErr := archive.Close(); closeErr != nil {
+		return nil, &RequestError{"backup_error", closeErr.Error()}
+	}
+
+	return manifest, nil
+}
+
+// Restore - recreates the folders and documents of a tar.gz or zip archive written by Backup in a project
+// the project is created when missing. Every document is checked against the content hash of the manifest
+// before anything is restored, documents that already exist are handled by the conflict policy.
+func (jsb *Instance) Restore(r io.Reader, targetProject string, options RestoreOptions) (*RestoreReport, *RequestError) {
+	if targetProject == "" {
+		return nil, &RequestError{"bad_request", "Project is required"}
+	}
+
+	if options.Conflict == "" {
+		options.Conflict = ConflictSkip
+	}
+
+	switch options.Conflict {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+	default:
+		return nil, &RequestError{"bad_request", "Unsupported conflict policy " + options.Conflict}
+	}
+
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+
+	if options.MaxFileSize <= 0 {
+		options.MaxFileSize = 64 << 20
+	}
+
+	if options.MaxTotalSize <= 0 {
+		options.MaxTotalSize = 1 << 30
+	}
+
+	manifest, contents, err := readBackup(r, &archiveBudget{maxFile: options.MaxFileSize, maxTotal: options.MaxTotalSize, remaining: options.MaxTotalSize})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := jsb.CreateProjectIfNotExists(types.CreateProjectBody{Name: targetProject}); err != nil {
+		return nil, err
+	}
+
+	documents, folders, _, err := jsb.remoteSyncEntries(targetProject, SyncOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Conflict == ConflictFail {
+		for _, document := range manifest.Documents {
+			if _, exists := documents[document.Path]; exists {
+				return nil, &RequestError{"conflict", "Document " + targetProject + "/" + document.Path + " already exists"}
+			}
+		}
+	}
+
+	report := &RestoreReport{}
+
+	// folders of the manifest and the parents of documents, parents are created before their children
+	needed := map[string]bool{}
+	for _, folder := range manifest.Folders {
+		needed[folder.Path] = true
+	}
+	for _, document := range manifest.Documents {
+		for dir := parentPath(document.Path); dir != ""; dir = parentPath(dir) {
+			needed[dir] = true
+		}
+	}
+
+	for _, folder := range sortedSet(needed) {
+		if folders[folder] {
+			continue
+		}
+
+		if _, err := jsb.EnsureFolderPath(targetProject + "/" + folder); err != nil {
+			return nil, err
+		}
+		folders[folder] = true
+		report.CreatedFolders = append(report.CreatedFolders, folder)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, options.Concurrency)
+
+	for _, document := range manifest.Documents {
+		document := document
+		_, exists := documents[document.Path]
+
+		if exists && options.Conflict == ConflictSkip {
+			report.Skipped = append(report.Skipped, document.Path)
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			// the verified content is written as is, parent folders were restored above
+			var err *RequestError
+			if exists {
+				_, err = jsb.updateRawDocument(targetProject+"/"+document.Path, string(contents[document.Path]), nil)
+			} else {
+				_, err = jsb.createRawDocument(types.CreateDocumentBody{
+					Name:    path.Base(document.Path),
+					Project: targetProject,
+					Folder:  parentPath(document.Path),
+				}, contents[document.Path])
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				report.Errors = append(report.Errors, SyncError{document.Path, err})
+			case exists:
+				report.Overwritten = append(report.Overwritten, document.Path)
+			default:
+				report.Created = append(report.Created, document.Path)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, list := range [][]string{report.Created, report.Overwritten, report.Skipped} {
+		sort.Strings(list)
+	}
+	sort.Slice(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Path < report.Errors[j].Path
+	})
+
+	return report, nil
+}
+
+// readBackup - reads the manifest and the document contents of an archive by path, contents are checked against the manifest
+func readBackup(r io.Reader, budget *archiveBudget) (*types.Manifest, map[string][]byte, *RequestError) {
+	// zip archives start with a local file header, tar.gz with the gzip magic number
+	buffered := bufio.NewReader(r)
+	magic, _ := buffered.Peek(4)
+
+	var files map[string][]byte
+	var readErr error
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		files, readErr = readZipArchive(buffered, budget)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		files, readErr = readTarGzArchive(buffered, budget)
+	default:
+		return nil, nil, &RequestError{"invalid_backup", "Backup is not a tar.gz or zip archive"}
+	}
+
+	if readErr != nil {
+		return nil, nil, &RequestError{"invalid_backup", readErr.Error()}
+	}
+
+	data, ok := files[backupManifest]
+	if !ok {
+		return nil, nil, &RequestError{"invalid_backup", "Backup has no manifest"}
+	}
+
+	var manifest types.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, &RequestError{"invalid_backup", "Invalid manifest: " + err.Error()}
+	}
+
+	contents := make(map[string][]byte, len(manifest.Documents))
+	for _, document := range manifest.Documents {
+		if _, err := localPath("", document.Path); err != nil {
+			return nil, nil, err
+		}
+
+		content, ok := files[backupDocuments+document.Path]
+		if !ok {
+			return nil, nil, &RequestError{"invalid_backup", "Backup has no content for " + document.Path}
+		}
+
+		if ContentHash(string(content)) != document.ContentHash {
+			return nil, nil, &RequestError{"checksum_mismatch", "Content of " + document.Path + " does not match the manifest"}
+		}
+		contents[document.Path] = content
+	}
+
+	for _, folder := range manifest.Folders {
+		if _, err := localPath("", folder.Path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return &manifest, contents, nil
+}
+
+// archiveBudget - limits the uncompressed size of the files read from an archive
+type archiveBudget struct {
+	maxFile   int64
+	maxTotal  int64
+	remaining int64
+}
+
+// read - reads a file of an archive, failing once the file or all files read are too large
+func (budget *archiveBudget) read(name string, r io.Reader) ([]byte, error) {
+	limit := budget.maxFile
+	if budget.remaining < limit {
+		limit = budget.remaining
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	size := int64(len(data))
+	if size > budget.maxFile {
+		return nil, fmt.Errorf("%v is larger than %v bytes", name, budget.maxFile)
+	}
+	if size > budget.remaining {
+		return nil, fmt.Errorf("Backup is larger than %v bytes", budget.maxTotal)
+	}
+
+	budget.remaining -= size
+	return data, nil
+}
+
+// readTarGzArchive - reads the regular files of a tar.gz archive
+func readTarGzArchive(r io.Reader, budget *archiveBudget) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	files := map[string][]byte{}
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := strings.TrimPrefix(header.Name, "./")
+		data, err := budget.read(name, archive)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = data
+	}
+}
+
+// readZipArchive - reads the files of a zip archive, which is buffered in memory to read its directory
+// the archive itself is limited to the total size of its files
+func readZipArchive(r io.Reader, budget *archiveBudget) (map[string][]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, budget.maxTotal+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > budget.maxTotal {
+		return nil, fmt.Errorf("Backup is larger than %v bytes", budget.maxTotal)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := budget.read(file.Name, reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[file.Name] = content
+	}
+
+	return files, nil
+}
+
+// archiveWriter - writes files to a backup archive
+type archiveWriter interface {
+	add(name string, data []byte, modTime time.Time) error
+	Close() error
+}
+
+// tarGzWriter - writes a tar archive compressed with gzip
+type tarGzWriter struct {
+	gz  *gzip.Writer
+	tar *tar.Writer
+}
+
+func newTarGzWriter(w io.Writer) *tarGzWriter {
+	gz := gzip.NewWriter(w)
+	return &tarGzWriter{gz: gz, tar: tar.NewWriter(gz)}
+}
+
+func (a *tarGzWriter) add(name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := a.tar.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := a.tar.Write(data)
+	return err
+}
+
+func (a *tarGzWriter) Close() error {
+	if err := a.tar.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+// zipArchiveWriter - writes a zip archive
+type zipArchiveWriter struct {
+	*zip.Writer
+}
+
+func (a *zipArchiveWriter) add(name string, data []byte, modTime time.Time) error {
+	file, err := a.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime})
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	return err
+}
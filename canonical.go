@@ -0,0 +1,239 @@
+package jsonbank
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Canonical - canonical formatting of content written by CreateDocument and UpdateOwnDocument
+type Canonical struct {
+	Enabled       bool   // Canonicalize content before it is written
+	Indent        string // Indentation of canonical content, empty for compact json
+	SkipUnchanged bool   // Skip updates that do not change the current content semantically
+}
+
+// CanonicalJson - formats json content canonically: object keys are sorted, numbers are formatted the same way
+// regardless of how they were written (1.0, 1e0 and 1 all become 1), and whitespace is normalized.
+// indent is used for nested values, an empty indent produces compact json.
+func CanonicalJson(content string, indent string) (string, *RequestError) {
+	value, err := decodeJson([]byte(content))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, value, indent, 0); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// IsSameJson - checks if two json strings are semantically equal, ignoring formatting and key order
+func IsSameJson(a string, b string) bool {
+	x, err := decodeJson([]byte(a))
+	if err != nil {
+		return false
+	}
+
+	y, err := decodeJson([]byte(b))
+	if err != nil {
+		return false
+	}
+
+	return valuesEqual(x, y)
+}
+
+// decodeJson - decodes json content keeping numbers as json.Number
+func decodeJson(content []byte) (any, *RequestError) {
+	if !json.Valid(content) {
+		return nil, &InvalidJsonError
+	}
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, &InvalidJsonError
+	}
+
+	return value, nil
+}
+
+// writeCanonical - writes a decoded value as canonical json
+func writeCanonical(buf *bytes.Buffer, value any, indent string, depth int) *RequestError {
+	newline := func(depth int) {
+		if indent != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(strings.Repeat(indent, depth))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+
+		buf.WriteByte('{')
+		for i, key := range sortedKeys(v) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			if indent != "" {
+				buf.WriteByte(' ')
+			}
+			if err := writeCanonical(buf, v[key], indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		buf.WriteByte('}')
+	case []any:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			if err := writeCanonical(buf, item, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		buf.WriteByte(']')
+	case string:
+		writeCanonicalString(buf, v)
+	case json.Number:
+		n, err := canonicalNumber(v.String())
+		if err != nil {
+			return err
+		}
+		buf.WriteString(n)
+	case float64:
+		n, err := canonicalNumber(strconv.FormatFloat(v, 'g', -1, 64))
+		if err != nil {
+			return err
+		}
+		buf.WriteString(n)
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	default:
+		return &RequestError{"json_error", "Unsupported value"}
+	}
+
+	return nil
+}
+
+// writeCanonicalString - writes a json string without escaping html characters
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+
+	// Encode terminates values with a newline
+	buf.Truncate(buf.Len() - 1)
+}
+
+// canonicalNumber - formats a json number canonically without changing its value
+// the decimal text is normalized: the sign of zero, leading and trailing zeros are dropped and the exponent is folded,
+// every significant digit is kept. Numbers from 1e-6 up to 1e21 and integers ending in a non-zero digit are written
+// without exponent, e.g. 100, 0.5 and 12345678901234567890123, other numbers as 1.5e+21 or 1e-7.
+func canonicalNumber(n string) (string, *RequestError) {
+	invalid := &RequestError{"json_error", "Invalid number " + n}
+
+	text := n
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(text, "-")
+
+	mantissa, exponentText, hasExponent := strings.Cut(strings.ToLower(text), "e")
+	integer, fraction, _ := strings.Cut(mantissa, ".")
+	if integer == "" || strings.Trim(integer+fraction, "0123456789") != "" {
+		return "", invalid
+	}
+
+	exponent := 0
+	if hasExponent {
+		e, err := strconv.Atoi(strings.TrimPrefix(exponentText, "+"))
+		if err != nil {
+			return "", invalid
+		}
+		exponent = e
+	}
+
+	// value = digits * 10^exponent
+	digits := strings.TrimLeft(integer+fraction, "0")
+	exponent -= len(fraction)
+	if digits == "" {
+		return "0", nil
+	}
+
+	trimmed := strings.TrimRight(digits, "0")
+	exponent += len(digits) - len(trimmed)
+	digits = trimmed
+
+	// position of the decimal point from the start of the digits
+	point := len(digits) + exponent
+	scientific := point - 1
+
+	var out strings.Builder
+	if negative {
+		out.WriteByte('-')
+	}
+
+	switch {
+	case exponent >= 0 && (scientific < 21 || exponent == 0):
+		out.WriteString(digits)
+		out.WriteString(strings.Repeat("0", exponent))
+	case scientific < 21 && scientific >= -6:
+		if point > 0 {
+			out.WriteString(digits[:point])
+			out.WriteByte('.')
+			out.WriteString(digits[point:])
+		} else {
+			out.WriteString("0.")
+			out.WriteString(strings.Repeat("0", -point))
+			out.WriteString(digits)
+		}
+	default:
+		out.WriteByte(digits[0])
+		if len(digits) > 1 {
+			out.WriteByte('.')
+			out.WriteString(digits[1:])
+		}
+		out.WriteByte('e')
+		if scientific > 0 {
+			out.WriteByte('+')
+		}
+		out.WriteString(strconv.Itoa(scientific))
+	}
+
+	return out.String(), nil
+}
+
+// canonicalizeContent - formats content canonically when enabled in the config
+func (jsb *Instance) canonicalizeContent(content []byte) ([]byte, *RequestError) {
+	if !jsb.config.Canonical.Enabled {
+		return content, nil
+	}
+
+	canonical, err := CanonicalJson(string(content), jsb.config.Canonical.Indent)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(canonical), nil
+}
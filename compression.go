@@ -0,0 +1,90 @@
+package jsonbank
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+)
+
+// defaultCompressionMinSize - request bodies smaller than this are sent uncompressed
+const defaultCompressionMinSize = 1024
+
+// Compression - gzip compression of request and response bodies
+type Compression struct {
+	Enabled bool // Compress request bodies and accept compressed responses
+	MinSize int  // Minimum request body size in bytes to compress, defaults to 1024
+}
+
+// gzipReadCloser - decompresses a response body and closes both readers
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (r *gzipReadCloser) Close() error {
+	_ = r.Reader.Close()
+	return r.body.Close()
+}
+
+// compressRequest - gzip the request body if it is large enough and ask for a compressed response
+func (jsb *Instance) compressRequest(req *http.Request) *RequestError {
+	if !jsb.config.Compression.Enabled {
+		return nil
+	}
+
+	// handle decompression ourselves, see decompressResponse
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	minSize := int64(jsb.config.Compression.MinSize)
+	if minSize <= 0 {
+		minSize = defaultCompressionMinSize
+	}
+
+	if req.Body == nil || req.ContentLength < minSize {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return &RequestError{"request_error", err.Error()}
+	}
+	_ = req.Body.Close()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, _ = writer.Write(body)
+	if err := writer.Close(); err != nil {
+		return &RequestError{"request_error", err.Error()}
+	}
+
+	compressed := buf.Bytes()
+	req.Body = io.NopCloser(bytes.NewReader(compressed))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	req.ContentLength = int64(len(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+
+	return nil
+}
+
+// decompressResponse - transparently decompress a gzip response body
+func decompressResponse(res *http.Response) *RequestError {
+	if res.Header.Get("Content-Encoding") != "gzip" {
+		return nil
+	}
+
+	reader, err := gzip.NewReader(res.Body)
+	if err != nil {
+		_ = res.Body.Close()
+		return &RequestError{"request_error", err.Error()}
+	}
+
+	res.Body = &gzipReadCloser{reader, res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+
+	return nil
+}
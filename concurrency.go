@@ -0,0 +1,119 @@
+package jsonbank
+
+import (
+	"encoding/json"
+	"github.com/jsonbankio/go-sdk/types"
+	"net/http"
+)
+
+// UpdateOwnDocumentIfUnchanged - updates a document only if it still matches the expected version
+// the version is verified immediately before writing and sent as If-Match header for servers that support it.
+// returns ConflictError when the document was changed since it was read
+func (jsb *Instance) UpdateOwnDocumentIfUnchanged(idOrPath string, content string, expected types.DocumentVersion) (*types.UpdatedDocument, *RequestError) {
+	if expected == (types.DocumentVersion{}) {
+		return nil, &RequestError{"bad_request", "Expected version is required"}
+	}
+
+	// check if content is valid json before checking the version
+	if err := jsb.validateContent([]byte(content)); err != nil {
+		return nil, err
+	}
+
+	if err := jsb.checkVersion(idOrPath, expected); err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	if expected.ContentHash != "" {
+		header.Set("If-Match", `"`+expected.ContentHash+`"`)
+	}
+
+	return jsb.updateOwnDocument(idOrPath, content, header)
+}
+
+// checkVersion - compares the current version of a document with the expected one
+// reads are fresh, a coalesced read could have started before the caller's own write
+func (jsb *Instance) checkVersion(idOrPath string, expected types.DocumentVersion) *RequestError {
+	if expected.UpdatedAt != "" || expected.ContentSize != 0 {
+		meta, err := jsb.getOwnDocumentMeta(idOrPath, true)
+		if err != nil {
+			return err
+		}
+
+		if expected.UpdatedAt != "" && meta.UpdatedAt != expected.UpdatedAt {
+			return &ConflictError
+		}
+
+		if expected.ContentSize != 0 && meta.ContentSize.Number != expected.ContentSize {
+			return &ConflictError
+		}
+	}
+
+	if expected.ContentHash != "" {
+		content, err := jsb.getOwnContentAsString(idOrPath, true)
+		if err != nil {
+			return err
+		}
+
+		if ContentHash(content) != expected.ContentHash {
+			return &ConflictError
+		}
+	}
+
+	return nil
+}
+
+// getOwnDocumentVersion - gets the content of a document together with its current version
+func (jsb *Instance) getOwnDocumentVersion(idOrPath string) (string, types.DocumentVersion, *RequestError) {
+	meta, err := jsb.getOwnDocumentMeta(idOrPath, true)
+	if err != nil {
+		return "", types.DocumentVersion{}, err
+	}
+
+	content, err := jsb.getOwnContentAsString(idOrPath, true)
+	if err != nil {
+		return "", types.DocumentVersion{}, err
+	}
+
+	version := meta.Version()
+	version.ContentHash = ContentHash(content)
+
+	return content, version, nil
+}
+
+// ModifyOwnDocument - reads a document, transforms its content and writes the result only if the document was not
+// changed in between. On conflict the whole read-transform-write cycle is retried up to maxRetries times.
+// returns the written content
+func (jsb *Instance) ModifyOwnDocument(idOrPath string, maxRetries int, transform func(content any) (any, error)) (any, *types.UpdatedDocument, *RequestError) {
+	for attempt := 0; ; attempt++ {
+		current, version, err := jsb.getOwnDocumentVersion(idOrPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		content, err := jsb.decodeContent([]byte(current))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		modified, transformErr := transform(content)
+		if transformErr != nil {
+			return nil, nil, &RequestError{"transform_error", transformErr.Error()}
+		}
+
+		newContent, jsonErr := json.Marshal(modified)
+		if jsonErr != nil {
+			return nil, nil, &RequestError{"json_error", jsonErr.Error()}
+		}
+
+		updated, err := jsb.UpdateOwnDocumentIfUnchanged(idOrPath, string(newContent), version)
+		if err != nil {
+			if err.Code == ConflictError.Code && attempt < maxRetries {
+				continue
+			}
+			return nil, nil, err
+		}
+
+		return modified, updated, nil
+	}
+}
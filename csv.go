@@ -0,0 +1,168 @@
+package jsonbank
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// csvToJson - converts csv content to a json array of objects
+// the first record names the fields and every value is kept as a string
+func csvToJson(content []byte) ([]byte, *RequestError) {
+	reader := csv.NewReader(bytes.NewReader(content))
+
+	invalid := func(err error) *RequestError {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return &RequestError{"invalid_csv", fmt.Sprintf("line %v, column %v: %v", parseErr.Line, parseErr.Column, parseErr.Err)}
+		}
+		return &RequestError{"invalid_csv", err.Error()}
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []byte("[]"), nil
+	} else if err != nil {
+		return nil, invalid(err)
+	}
+
+	seen := map[string]bool{}
+	for _, name := range header {
+		if seen[name] {
+			return nil, &RequestError{"invalid_csv", fmt.Sprintf("line 1: duplicate field %q", name)}
+		}
+		seen[name] = true
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := 0; ; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, invalid(err)
+		}
+
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		buf.WriteByte('{')
+		for j, name := range header {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(&buf, name)
+			buf.WriteByte(':')
+			writeCanonicalString(&buf, record[j])
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+
+	return buf.Bytes(), nil
+}
+
+// jsonToCsv - converts a json array of objects to csv
+// columns are ordered as the fields first appear in the content, strings are written as they are,
+// other values as json and missing fields or null as empty values
+func jsonToCsv(content []byte) ([]byte, *RequestError) {
+	unsupported := &RequestError{"unsupported_content", "Only arrays of objects can be converted to csv"}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		if err != nil {
+			return nil, &InvalidJsonError
+		}
+		return nil, unsupported
+	}
+
+	var columns []string
+	index := map[string]int{}
+	var rows []map[string]string
+
+	for decoder.More() {
+		var fields []struct {
+			name  string
+			value json.RawMessage
+		}
+
+		if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+			if err != nil {
+				return nil, &InvalidJsonError
+			}
+			return nil, unsupported
+		}
+
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, &InvalidJsonError
+			}
+
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, &InvalidJsonError
+			}
+
+			fields = append(fields, struct {
+				name  string
+				value json.RawMessage
+			}{key.(string), value})
+		}
+
+		if _, err := decoder.Token(); err != nil {
+			return nil, &InvalidJsonError
+		}
+
+		row := map[string]string{}
+		for _, field := range fields {
+			if _, ok := index[field.name]; !ok {
+				index[field.name] = len(columns)
+				columns = append(columns, field.name)
+			}
+			row[field.name] = csvValue(field.value)
+		}
+		rows = append(rows, row)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, &InvalidJsonError
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write(columns)
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = row[column]
+		}
+		_ = writer.Write(record)
+	}
+	writer.Flush()
+
+	return buf.Bytes(), nil
+}
+
+// csvValue - formats a json value as a csv field
+func csvValue(value json.RawMessage) string {
+	var s string
+	if json.Unmarshal(value, &s) == nil {
+		return s
+	}
+
+	if string(value) == "null" {
+		return ""
+	}
+
+	var compact bytes.Buffer
+	_ = json.Compact(&compact, value)
+	return compact.String()
+}
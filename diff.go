@@ -0,0 +1,380 @@
+package jsonbank
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jsonbankio/go-sdk/types"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// diffContextLines - unchanged lines shown around each hunk of a unified diff
+const diffContextLines = 3
+
+// Diff - compares two json values structurally and returns the changes that turn from into to
+// objects are compared key by key and arrays index by index
+func Diff(from any, to any) ([]types.Change, *RequestError) {
+	a, err := normalizeJson(from)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := normalizeJson(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffValues(nil, "", a, b), nil
+}
+
+// diffValues - appends the changes between a and b at path
+func diffValues(changes []types.Change, path string, a any, b any) []types.Change {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+
+		for _, key := range sortedKeys(x) {
+			childPath := path + MakePointer(key)
+			if value, ok := y[key]; ok {
+				changes = diffValues(changes, childPath, x[key], value)
+			} else {
+				changes = append(changes, types.Change{Op: "remove", Path: childPath, OldValue: x[key]})
+			}
+		}
+
+		for _, key := range sortedKeys(y) {
+			if _, ok := x[key]; !ok {
+				changes = append(changes, types.Change{Op: "add", Path: path + MakePointer(key), NewValue: y[key]})
+			}
+		}
+
+		return changes
+	case []any:
+		y, ok := b.([]any)
+		if !ok {
+			break
+		}
+
+		common := len(x)
+		if len(y) < common {
+			common = len(y)
+		}
+
+		for i := 0; i < common; i++ {
+			changes = diffValues(changes, path+"/"+strconv.Itoa(i), x[i], y[i])
+		}
+
+		for i := common; i < len(y); i++ {
+			changes = append(changes, types.Change{Op: "add", Path: path + "/" + strconv.Itoa(i), NewValue: y[i]})
+		}
+
+		// remove from the end so that the changes can be applied in order
+		for i := len(x) - 1; i >= common; i-- {
+			changes = append(changes, types.Change{Op: "remove", Path: path + "/" + strconv.Itoa(i), OldValue: x[i]})
+		}
+
+		return changes
+	}
+
+	if !valuesEqual(a, b) {
+		changes = append(changes, types.Change{Op: "replace", Path: path, OldValue: a, NewValue: b})
+	}
+
+	return changes
+}
+
+// ChangesToPatch - converts changes returned by Diff to RFC 6902 patch operations
+func ChangesToPatch(changes []types.Change) []types.PatchOperation {
+	operations := make([]types.PatchOperation, len(changes))
+	for i, change := range changes {
+		operations[i] = types.PatchOperation{Op: change.Op, Path: change.Path, Value: change.NewValue}
+	}
+
+	return operations
+}
+
+// FormatChanges - renders changes as human-readable text, one change per line
+func FormatChanges(changes []types.Change) string {
+	var b strings.Builder
+	for _, change := range changes {
+		path := change.Path
+		if path == "" {
+			path = "/"
+		}
+
+		switch change.Op {
+		case "add":
+			fmt.Fprintf(&b, "+ %v: %v\n", path, compactJson(change.NewValue))
+		case "remove":
+			fmt.Fprintf(&b, "- %v: %v\n", path, compactJson(change.OldValue))
+		default:
+			fmt.Fprintf(&b, "~ %v: %v -> %v\n", path, compactJson(change.OldValue), compactJson(change.NewValue))
+		}
+	}
+
+	return b.String()
+}
+
+// compactJson - formats a value as compact json
+func compactJson(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// UnifiedDiff - renders the differences between two json values as a unified diff of their canonical json
+// fromName and toName are used in the --- and +++ headers. Returns an empty string when the values are equal.
+func UnifiedDiff(from any, to any, fromName string, toName string) (string, *RequestError) {
+	a, err := json.MarshalIndent(from, "", "  ")
+	if err != nil {
+		return "", &RequestError{"json_error", err.Error()}
+	}
+
+	b, err := json.MarshalIndent(to, "", "  ")
+	if err != nil {
+		return "", &RequestError{"json_error", err.Error()}
+	}
+
+	edits := diffLines(strings.Split(string(a), "\n"), strings.Split(string(b), "\n"))
+
+	var out strings.Builder
+	for _, hunk := range diffHunks(edits) {
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %v\n+++ %v\n", fromName, toName)
+		}
+		out.WriteString(hunk)
+	}
+
+	return out.String(), nil
+}
+
+// lineEdit - a line of an edit script
+type lineEdit struct {
+	kind byte // ' ' unchanged, '-' removed, '+' added
+	text string
+}
+
+// diffLines - computes the shortest edit script between two lists of lines (Myers' algorithm)
+// only the diagonals reachable after d edits are kept for each d, so memory grows with the size of the diff
+func diffLines(a []string, b []string) []lineEdit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace[d][k+d] - furthest x on diagonal k after d edits
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		if done {
+			return backtrackEdits(a, b, trace)
+		}
+	}
+
+	return nil
+}
+
+// backtrackEdits - walks the trace of diffLines back to build the edit script
+func backtrackEdits(a []string, b []string, trace [][]int) []lineEdit {
+	var edits []lineEdit
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, lineEdit{' ', a[x-1]})
+			x--
+			y--
+		}
+
+		if x == prevX {
+			edits = append(edits, lineEdit{'+', b[y-1]})
+		} else {
+			edits = append(edits, lineEdit{'-', a[x-1]})
+		}
+
+		x, y = prevX, prevY
+	}
+
+	// lines shared by both lists before the first edit
+	for x > 0 && y > 0 {
+		edits = append(edits, lineEdit{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	// edits were collected backwards
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
+
+// diffHunks - groups an edit script into unified diff hunks
+func diffHunks(edits []lineEdit) []string {
+	var hunks []string
+
+	for start := 0; start < len(edits); {
+		// find the next change
+		for start < len(edits) && edits[start].kind == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		first := start - diffContextLines
+		if first < 0 {
+			first = 0
+		}
+
+		// extend the hunk while changes are close to each other
+		last := start
+		for i := start; i < len(edits); i++ {
+			if edits[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContextLines {
+				break
+			}
+		}
+
+		end := last + diffContextLines + 1
+		if end > len(edits) {
+			end = len(edits)
+		}
+
+		// line numbers of the hunk start
+		fromLine, toLine := 1, 1
+		for _, edit := range edits[:first] {
+			if edit.kind != '+' {
+				fromLine++
+			}
+			if edit.kind != '-' {
+				toLine++
+			}
+		}
+
+		var body strings.Builder
+		fromCount, toCount := 0, 0
+		for _, edit := range edits[first:end] {
+			if edit.kind != '+' {
+				fromCount++
+			}
+			if edit.kind != '-' {
+				toCount++
+			}
+			body.WriteByte(edit.kind)
+			body.WriteString(edit.text)
+			body.WriteByte('\n')
+		}
+
+		hunks = append(hunks, fmt.Sprintf("@@ -%v +%v @@\n%v", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount), body.String()))
+		start = end
+	}
+
+	return hunks
+}
+
+// hunkRange - formats the line range of a hunk
+func hunkRange(line int, count int) string {
+	if count == 0 {
+		// empty ranges point to the line before
+		return fmt.Sprintf("%v,0", line-1)
+	}
+	if count == 1 {
+		return strconv.Itoa(line)
+	}
+	return fmt.Sprintf("%v,%v", line, count)
+}
+
+// DiffOwnDocuments - compares two documents owned by the authenticated user, numbers are compared exactly
+func (jsb *Instance) DiffOwnDocuments(fromIdOrPath string, toIdOrPath string) ([]types.Change, *RequestError) {
+	from, err := jsb.getOwnContentExact(fromIdOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := jsb.getOwnContentExact(toIdOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return Diff(from, to)
+}
+
+// DiffOwnDocumentWithFile - returns the changes that uploading a local json file would make to a document
+func (jsb *Instance) DiffOwnDocumentWithFile(idOrPath string, filePath string) ([]types.Change, *RequestError) {
+	content, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return nil, &RequestError{"invalid_file", "Could not read file"}
+	}
+
+	local, err := decodeJson(content)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := jsb.getOwnContentExact(idOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return Diff(remote, local)
+}
+
+// DiffInstances - compares documents owned by the users of two instances, e.g. a staging and a production host
+// numbers are compared exactly
+func DiffInstances(from *Instance, fromIdOrPath string, to *Instance, toIdOrPath string) ([]types.Change, *RequestError) {
+	a, err := from.getOwnContentExact(fromIdOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := to.getOwnContentExact(toIdOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return Diff(a, b)
+}
@@ -12,3 +12,5 @@ func (error *RequestError) Error() string {
 }
 
 var InvalidJsonError = RequestError{"invalid_json_content", "Content is not a valid JSON string"}
+
+var ConflictError = RequestError{"conflict", "Document was modified since it was read"}
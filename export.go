@@ -0,0 +1,212 @@
+package jsonbank
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jsonbankio/go-sdk/types"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ManifestFile - name of the manifest written by ExportProject in the export directory
+const ManifestFile = ".jsonbank.manifest.json"
+
+// ExportOptions - options of ExportProject
+type ExportOptions struct {
+	Manifest    bool // Write a manifest with the id, path, update time and content hash of every document
+	Incremental bool // Only download documents updated since the manifest of a previous export
+	Concurrency int  // Maximum number of concurrent requests, 4 by default
+}
+
+// ExportReport - documents written by ExportProject, paths are relative to the export directory
+type ExportReport struct {
+	Written []string
+	Skipped []string // unchanged since the previous export
+	Errors  []SyncError
+}
+
+// ExportProject - downloads every document of a project into a directory with the same layout
+// files are written atomically, folders without documents are created as empty directories
+func (jsb *Instance) ExportProject(project string, localDir string, options ExportOptions) (*ExportReport, *RequestError) {
+	if project == "" {
+		return nil, &RequestError{"bad_request", "Project is required"}
+	}
+
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+
+	manifest, err := jsb.projectManifest(project, options.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	// documents of the previous export
+	previous := map[string]types.ManifestDocument{}
+	if options.Incremental {
+		if data, readErr := os.ReadFile(filepath.Join(localDir, ManifestFile)); readErr == nil {
+			var old types.Manifest
+			if json.Unmarshal(data, &old) == nil && old.Project == project {
+				for _, document := range old.Documents {
+					previous[document.Id] = document
+				}
+			}
+		}
+	}
+
+	for _, folder := range manifest.Folders {
+		dir, err := localPath(localDir, folder.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if mkdirErr := os.MkdirAll(dir, 0o755); mkdirErr != nil {
+			return nil, &RequestError{"invalid_directory", mkdirErr.Error()}
+		}
+	}
+
+	report := &ExportReport{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, options.Concurrency)
+
+	for i := range manifest.Documents {
+		document := &manifest.Documents[i]
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			written, err := jsb.exportDocument(localDir, document, previous)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				report.Errors = append(report.Errors, SyncError{document.Path, err})
+			case written:
+				report.Written = append(report.Written, document.Path)
+			default:
+				report.Skipped = append(report.Skipped, document.Path)
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Strings(report.Written)
+	sort.Strings(report.Skipped)
+	sort.Slice(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Path < report.Errors[j].Path
+	})
+
+	if options.Manifest {
+		data, _ := json.MarshalIndent(manifest, "", "  ")
+		if writeErr := writeFileAtomic(filepath.Join(localDir, ManifestFile), data); writeErr != nil {
+			return nil, &RequestError{"invalid_directory", writeErr.Error()}
+		}
+	}
+
+	return report, nil
+}
+
+// exportDocument - downloads a document unless the local file is unchanged since the previous export
+// sets the content hash of the document, returns whether the file was written
+func (jsb *Instance) exportDocument(localDir string, document *types.ManifestDocument, previous map[string]types.ManifestDocument) (bool, *RequestError) {
+	file, err := localPath(localDir, document.Path)
+	if err != nil {
+		return false, err
+	}
+
+	if old, ok := previous[document.Id]; ok && old.UpdatedAt == document.UpdatedAt && old.Path == document.Path {
+		if data, readErr := os.ReadFile(file); readErr == nil && ContentHash(string(data)) == old.ContentHash {
+			document.ContentHash = old.ContentHash
+			return false, nil
+		}
+	}
+
+	content, err := jsb.GetOwnContentAsString(document.Id)
+	if err != nil {
+		return false, err
+	}
+
+	if writeErr := writeFileAtomic(file, []byte(content)); writeErr != nil {
+		return false, &RequestError{"invalid_file", writeErr.Error()}
+	}
+
+	document.ContentHash = ContentHash(content)
+	return true, nil
+}
+
+// projectManifest - lists every document and folder of a project, content hashes are not set
+func (jsb *Instance) projectManifest(project string, concurrency int) (*types.Manifest, *RequestError) {
+	manifest := &types.Manifest{Project: project, Documents: []types.ManifestDocument{}, Folders: []types.Folder{}}
+
+	var failure *RequestError
+	walkErr := jsb.WalkWithOptions(context.Background(), project, WalkOptions{Concurrency: concurrency}, func(entry *types.WalkEntry, err *RequestError) error {
+		if err != nil {
+			failure = err
+			return err
+		}
+
+		if entry.Document != nil {
+			manifest.Documents = append(manifest.Documents, types.ManifestDocument{DocumentMeta: *entry.Document})
+		} else if entry.Folder != nil {
+			manifest.Folders = append(manifest.Folders, *entry.Folder)
+		}
+		return nil
+	})
+
+	if failure != nil {
+		return nil, failure
+	}
+	if walkErr != nil {
+		return nil, &RequestError{"export_error", walkErr.Error()}
+	}
+
+	return manifest, nil
+}
+
+// localPath - returns the local path of a document or folder path, paths leaving the directory are rejected
+func localPath(localDir string, remotePath string) (string, *RequestError) {
+	clean := path.Clean(remotePath)
+	if clean != remotePath || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") || path.IsAbs(clean) {
+		return "", &RequestError{"invalid_path", "Invalid path " + remotePath}
+	}
+
+	return filepath.Join(localDir, filepath.FromSlash(clean)), nil
+}
+
+// writeFileAtomic - writes a file through a temporary file in the same directory
+// so that readers never see a partially written file
+func writeFileAtomic(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), name)
+	}
+
+	if err != nil {
+		_ = os.Remove(temp.Name())
+	}
+	return err
+}
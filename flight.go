@@ -0,0 +1,71 @@
+package jsonbank
+
+import "sync"
+
+// flightCall - a request shared by concurrent callers
+type flightCall struct {
+	wg   sync.WaitGroup
+	data any
+	err  *RequestError
+}
+
+// flightGroup - coalesces concurrent identical requests into a single one
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do - runs fn once for all callers that ask for the same key at the same time
+// every caller receives the same result, callers that modify it must copy it first, see copyJsonValue
+func (g *flightGroup) do(key string, fn func() (any, *RequestError)) (any, *RequestError) {
+	// instances not created with Init have no group
+	if g == nil {
+		return fn()
+	}
+
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+
+	// wait for the request already in flight
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.data, c.err
+	}
+
+	c := &flightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.data, c.err = fn()
+	return c.data, c.err
+}
+
+// copyJsonValue - returns a deep copy of a decoded json value, strings and numbers are immutable and shared
+func copyJsonValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, item := range v {
+			copied[key] = copyJsonValue(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = copyJsonValue(item)
+		}
+		return copied
+	}
+	return value
+}
@@ -0,0 +1,95 @@
+package jsonbank
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Formats of content accepted by CreateDocument and UploadDocument
+const (
+	FormatJson  = "json"
+	FormatJsonc = "jsonc" // json with comments and trailing commas
+	FormatJson5 = "json5" // https://json5.org
+	FormatYaml  = "yaml"
+	FormatToml  = "toml"
+	FormatCsv   = "csv" // the first record names the fields of the objects of an array
+)
+
+// ConvertToJson - converts content written in a format to strict json
+// conversion errors report the line and column of the problem when it is known
+func ConvertToJson(content []byte, format string) ([]byte, *RequestError) {
+	switch strings.ToLower(format) {
+	case "", FormatJson:
+		return content, nil
+	case FormatJsonc:
+		return convertJson5(content, false)
+	case FormatJson5:
+		return convertJson5(content, true)
+	case FormatYaml, "yml":
+		return yamlToJson(content)
+	case FormatToml:
+		return tomlToJson(content)
+	case FormatCsv:
+		return csvToJson(content)
+	}
+
+	return nil, &RequestError{"unsupported_format", "Format " + format + " is not supported"}
+}
+
+// ConvertFromJson - converts json content to a format
+// toml requires an object and csv an array of objects, json5 and jsonc content is returned as json
+func ConvertFromJson(content []byte, format string) ([]byte, *RequestError) {
+	switch strings.ToLower(format) {
+	case "", FormatJson, FormatJsonc, FormatJson5:
+		if !IsValidJsonString(string(content)) {
+			return nil, &InvalidJsonError
+		}
+		return content, nil
+	case FormatYaml, "yml":
+		return jsonToYaml(content)
+	case FormatToml:
+		return jsonToToml(content)
+	case FormatCsv:
+		return jsonToCsv(content)
+	}
+
+	return nil, &RequestError{"unsupported_format", "Format " + format + " is not supported"}
+}
+
+// FormatFromPath - detects the format of a file from its extension, json when the extension is unknown
+func FormatFromPath(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".jsonc":
+		return FormatJsonc
+	case ".json5":
+		return FormatJson5
+	case ".yaml", ".yml":
+		return FormatYaml
+	case ".toml":
+		return FormatToml
+	case ".csv":
+		return FormatCsv
+	}
+
+	return FormatJson
+}
+
+// jsonName - replaces the extension of a file name with .json
+func jsonName(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".json"
+}
+
+// GetOwnContentAs - gets the content of a document owned by the authenticated user rendered in a format
+func (jsb *Instance) GetOwnContentAs(idOrPath string, format string) (string, *RequestError) {
+	content, err := jsb.GetOwnContentAsString(idOrPath)
+	if err != nil {
+		return "", err
+	}
+
+	converted, err := ConvertFromJson([]byte(content), format)
+	if err != nil {
+		return "", err
+	}
+
+	return string(converted), nil
+}
@@ -0,0 +1,255 @@
+package jsonbank
+
+import (
+	"bytes"
+	"github.com/jsonbankio/go-sdk/types"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// ProjectFS - a read-only fs.FS over the documents and folders of a project owned by the authenticated user
+// folders are directories and documents are files, paths are relative to the root of the project.
+// Stat, ReadDir and open files report the content size stored by the server, the content read may differ in
+// formatting: use Read or Seek on an open file to get its length.
+//
+//	templates, err := template.ParseFS(jsb.ProjectFS("sdk-test"), "templates/*.json")
+//	http.Handle("/", http.FileServer(http.FS(jsb.ProjectFS("sdk-test"))))
+type ProjectFS struct {
+	jsb     *Instance
+	project string
+}
+
+// ProjectFS - returns a fs.FS over a project
+func (jsb *Instance) ProjectFS(project string) *ProjectFS {
+	return &ProjectFS{jsb: jsb, project: project}
+}
+
+var (
+	_ fs.ReadFileFS = (*ProjectFS)(nil)
+	_ fs.ReadDirFS  = (*ProjectFS)(nil)
+	_ fs.StatFS     = (*ProjectFS)(nil)
+)
+
+// Open - opens a document or folder
+func (pfs *ProjectFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	info, err := pfs.stat(name)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+
+	if info.IsDir() {
+		entries, err := pfs.readDir(name)
+		if err != nil {
+			return nil, pathError("open", name, err)
+		}
+		return &projectDir{info: info, entries: entries}, nil
+	}
+
+	content, err := pfs.jsb.GetOwnContentAsString(pfs.fullPath(name))
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+
+	return &projectFile{info: info, Reader: bytes.NewReader([]byte(content))}, nil
+}
+
+// ReadFile - reads the content of a document
+func (pfs *ProjectFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+
+	content, err := pfs.jsb.GetOwnContentAsString(pfs.fullPath(name))
+	if err != nil {
+		return nil, pathError("readfile", name, err)
+	}
+
+	return []byte(content), nil
+}
+
+// ReadDir - lists a folder sorted by name
+func (pfs *ProjectFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries, err := pfs.readDir(name)
+	if err != nil {
+		return nil, pathError("readdir", name, err)
+	}
+
+	return entries, nil
+}
+
+// Stat - describes a document or folder
+func (pfs *ProjectFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	info, err := pfs.stat(name)
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+
+	return info, nil
+}
+
+// fullPath - returns the path of a name including the project
+func (pfs *ProjectFS) fullPath(name string) string {
+	return pfs.project + "/" + name
+}
+
+// stat - gets a document or, when there is none, a folder
+func (pfs *ProjectFS) stat(name string) (*projectFileInfo, *RequestError) {
+	if name == "." {
+		return &projectFileInfo{name: ".", dir: true}, nil
+	}
+
+	meta, err := pfs.jsb.GetOwnDocumentMeta(pfs.fullPath(name))
+	if err == nil {
+		return documentInfo(meta), nil
+	} else if err.Code != "notFound" {
+		return nil, err
+	}
+
+	folder, err := pfs.jsb.GetFolder(pfs.fullPath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return folderInfo(folder), nil
+}
+
+// readDir - lists the project root or a folder
+func (pfs *ProjectFS) readDir(name string) ([]fs.DirEntry, *RequestError) {
+	var contents *types.FolderContents
+	var err *RequestError
+	if name == "." {
+		contents, err = pfs.jsb.ListProject(pfs.project)
+	} else {
+		contents, err = pfs.jsb.ListFolder(pfs.fullPath(name))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]fs.DirEntry, 0, len(contents.Documents)+len(contents.Folders))
+	for i := range contents.Folders {
+		entries = append(entries, folderInfo(&contents.Folders[i]))
+	}
+	for i := range contents.Documents {
+		entries = append(entries, documentInfo(&contents.Documents[i]))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+// pathError - converts a request error to a fs.PathError, missing documents and folders become fs.ErrNotExist
+func pathError(op string, name string, err *RequestError) error {
+	if err.Code == "notFound" {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// projectFileInfo - fs.FileInfo and fs.DirEntry of a document or folder
+type projectFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	sys     any
+}
+
+// documentInfo - describes a document, the size is its content size as reported by the server
+// and the modification time its last update
+func documentInfo(meta *types.DocumentMeta) *projectFileInfo {
+	return &projectFileInfo{name: meta.Name, size: meta.ContentSize.Number, modTime: parseTime(meta.UpdatedAt), sys: meta}
+}
+
+// folderInfo - describes a folder
+func folderInfo(folder *types.Folder) *projectFileInfo {
+	return &projectFileInfo{name: path.Base(folder.Path), modTime: parseTime(folder.UpdatedAt), dir: true, sys: folder}
+}
+
+// parseTime - parses a time returned by the server, zero when it cannot be parsed
+func parseTime(value string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, value)
+	return t
+}
+
+func (info *projectFileInfo) Name() string       { return info.name }
+func (info *projectFileInfo) Size() int64        { return info.size }
+func (info *projectFileInfo) ModTime() time.Time { return info.modTime }
+func (info *projectFileInfo) IsDir() bool        { return info.dir }
+func (info *projectFileInfo) Sys() any           { return info.sys }
+
+func (info *projectFileInfo) Mode() fs.FileMode {
+	if info.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (info *projectFileInfo) Type() fs.FileMode          { return info.Mode().Type() }
+func (info *projectFileInfo) Info() (fs.FileInfo, error) { return info, nil }
+
+// projectFile - an open document
+type projectFile struct {
+	*bytes.Reader
+	info *projectFileInfo
+}
+
+func (f *projectFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *projectFile) Close() error               { return nil }
+
+// projectDir - an open folder
+type projectDir struct {
+	info    *projectFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *projectDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *projectDir) Close() error               { return nil }
+
+func (d *projectDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir - reads the next n entries, or all remaining entries when n <= 0
+func (d *projectDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+
+	return remaining[:n], nil
+}
@@ -2,9 +2,13 @@ package jsonbank
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"github.com/jsonbankio/go-sdk/types"
 	"io"
+	"math/big"
+	"strconv"
 )
 
 // MakeDocumentPath - generate a document full path
@@ -35,3 +39,115 @@ func JsonToReader(s any) io.Reader {
 	body, _ := json.Marshal(s)
 	return bytes.NewReader(body)
 }
+
+// ContentHash - returns the hex encoded sha256 hash of a document content
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeJson - returns a deep copy of a value made of plain json types
+// (map[string]any, []any, float64, json.Number, string, bool, nil). Other Go values, e.g. structs, are converted
+// through json and their numbers become json.Number so that no precision is lost.
+func normalizeJson(value any) (any, *RequestError) {
+	switch v := value.(type) {
+	case nil, bool, string, float64, json.Number:
+		return v, nil
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, child := range v {
+			c, err := normalizeJson(child)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = c
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, child := range v {
+			c, err := normalizeJson(child)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = c
+		}
+		return result, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, &RequestError{"json_error", err.Error()}
+	}
+
+	return decodeJson(data)
+}
+
+// valuesEqual - compares two decoded json values, numbers are compared by value
+func valuesEqual(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for key, value := range x {
+			other, ok := y[key]
+			if !ok || !valuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for i := range x {
+			if !valuesEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case float64, json.Number:
+		n, ok := numberValue(a)
+		m, isNumber := numberValue(b)
+		return ok && isNumber && n.Cmp(m) == 0
+	}
+
+	return a == b
+}
+
+// numberValue - converts a decoded json number to a big.Float
+func numberValue(value any) (*big.Float, bool) {
+	s, ok := numberText(value)
+	if !ok {
+		return nil, false
+	}
+
+	f, _, err := big.ParseFloat(s, 10, 256, big.ToNearestEven)
+	return f, err == nil
+}
+
+// ratValue - converts a decoded json number to an exact big.Rat
+func ratValue(value any) (*big.Rat, bool) {
+	s, ok := numberText(value)
+	if !ok {
+		return nil, false
+	}
+
+	return new(big.Rat).SetString(s)
+}
+
+// numberText - returns the decimal text of a decoded json number
+func numberText(value any) (string, bool) {
+	switch n := value.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'g', -1, 64), true
+	case json.Number:
+		return n.String(), true
+	}
+	return "", false
+}
@@ -2,4 +2,8 @@ module github.com/jsonbankio/go-sdk
 
 go 1.19
 
-require github.com/joho/godotenv v1.4.0
+require (
+	github.com/BurntSushi/toml v1.2.1
+	github.com/joho/godotenv v1.4.0
+	gopkg.in/yaml.v3 v3.0.1
+)
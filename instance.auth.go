@@ -3,10 +3,16 @@ package jsonbank
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/jsonbankio/go-sdk/types"
+	"io"
+	"io/fs"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
 // Authenticate - authenticates the jsonbank instance
@@ -66,7 +72,7 @@ func (jsb *Instance) GetOwnContent(idOrPath string) (any, *RequestError) {
 	}
 
 	// make request
-	data, err := jsb.sendRequest(req)
+	data, err := jsb.sendCoalescedRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -76,13 +82,24 @@ func (jsb *Instance) GetOwnContent(idOrPath string) (any, *RequestError) {
 
 // GetOwnContentAsString - gets the content of a document owned by the authenticated user as string
 func (jsb *Instance) GetOwnContentAsString(idOrPath string) (string, *RequestError) {
+	return jsb.getOwnContentAsString(idOrPath, false)
+}
+
+// getOwnContentAsString - gets the content of a document as string
+// fresh reads never join a request that is already in flight, so they see every write made before them
+func (jsb *Instance) getOwnContentAsString(idOrPath string, fresh bool) (string, *RequestError) {
 	req, err := jsb.makeRequest("GET", jsb.urls.v1+"/file/"+idOrPath, nil)
 	if err != nil {
 		return "", err
 	}
 
 	// make request
-	data, err := jsb.sendRequestAsText(req)
+	var data *string
+	if fresh {
+		data, err = jsb.sendRequestAsText(req)
+	} else {
+		data, err = jsb.sendCoalescedRequestAsText(req)
+	}
 
 	if err != nil {
 		return "", err
@@ -91,15 +108,36 @@ func (jsb *Instance) GetOwnContentAsString(idOrPath string) (string, *RequestErr
 	return *data, nil
 }
 
+// getOwnContentExact - gets the content of a document with numbers decoded as json.Number regardless of UseNumber
+// used when content is modified and written back, so that no precision is lost
+func (jsb *Instance) getOwnContentExact(idOrPath string) (any, *RequestError) {
+	content, err := jsb.GetOwnContentAsString(idOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJson([]byte(content))
+}
+
 // GetOwnDocumentMeta - gets the content meta of the authenticated user
 func (jsb *Instance) GetOwnDocumentMeta(idOrPath string) (*types.DocumentMeta, *RequestError) {
+	return jsb.getOwnDocumentMeta(idOrPath, false)
+}
+
+// getOwnDocumentMeta - gets the meta of a document, fresh reads never join a request that is already in flight
+func (jsb *Instance) getOwnDocumentMeta(idOrPath string, fresh bool) (*types.DocumentMeta, *RequestError) {
 	req, err := jsb.makeRequest("GET", jsb.urls.v1+"/meta/file/"+idOrPath, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// make request
-	d, err := jsb.sendRequest(req)
+	var d any
+	if fresh {
+		d, err = jsb.sendRequest(req)
+	} else {
+		d, err = jsb.sendCoalescedRequest(req)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +149,31 @@ func (jsb *Instance) GetOwnDocumentMeta(idOrPath string) (*types.DocumentMeta, *
 
 // CreateDocument - creates a document
 func (jsb *Instance) CreateDocument(document types.CreateDocumentBody) (*types.NewDocument, *RequestError) {
+	return jsb.createDocument(document, []byte(document.Content))
+}
+
+// CreateDocumentFrom - creates a document with content read from a reader, document.Content is ignored
+func (jsb *Instance) CreateDocumentFrom(document types.CreateDocumentBody, content io.Reader) (*types.NewDocument, *RequestError) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, &RequestError{"invalid_content", "Could not read content"}
+	}
+
+	return jsb.createDocument(document, data)
+}
+
+// CreateDocumentFromValue - creates a document with a Go value marshalled as content, document.Content is ignored
+func (jsb *Instance) CreateDocumentFromValue(document types.CreateDocumentBody, value any) (*types.NewDocument, *RequestError) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, &RequestError{"invalid_content", err.Error()}
+	}
+
+	return jsb.createDocument(document, data)
+}
+
+// createDocument - creates a document with content given as bytes
+func (jsb *Instance) createDocument(document types.CreateDocumentBody, content []byte) (*types.NewDocument, *RequestError) {
 	// project is required
 	if document.Project == "" {
 		return nil, &RequestError{"bad_request", "Project is required"}
@@ -120,15 +183,50 @@ func (jsb *Instance) CreateDocument(document types.CreateDocumentBody) (*types.N
 		return nil, &RequestError{"bad_request", "Name is required"}
 	}
 
-	url := fmt.Sprintf("/project/%s/document", document.Project)
+	// convert content written in other formats to json
+	content, err := ConvertToJson(content, document.Format)
+	if err != nil {
+		return nil, err
+	}
 
-	// check if content is a valid json string
-	if !IsValidJsonString(document.Content) {
-		return nil, &InvalidJsonError
+	// check if content is valid json, before canonical formatting drops duplicate keys
+	if err := jsb.validateContent(content); err != nil {
+		return nil, err
+	}
+
+	// format content canonically if enabled
+	content, err = jsb.canonicalizeContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// check if content matches the schemas of the document
+	if err := jsb.validateDocumentSchemas(MakeDocumentPath(document), content); err != nil {
+		return nil, err
 	}
 
+	// create missing parent folders once the content passed every check
+	if document.CreateFolders && document.Folder != "" {
+		if _, err := jsb.EnsureFolderPath(document.Project + "/" + document.Folder); err != nil {
+			return nil, err
+		}
+	}
+
+	return jsb.createRawDocument(document, content)
+}
+
+// createRawDocument - creates a document with json content as is, without conversion, validation or canonical formatting
+// used to copy and restore documents byte for byte
+func (jsb *Instance) createRawDocument(document types.CreateDocumentBody, content []byte) (*types.NewDocument, *RequestError) {
+	url := fmt.Sprintf("/project/%s/document", document.Project)
+
 	// convert document to reader
-	body, _ := json.Marshal(document)
+	body, _ := json.Marshal(createDocumentRequest{
+		Name:    document.Name,
+		Project: document.Project,
+		Folder:  document.Folder,
+		Content: jsonText(content),
+	})
 
 	// send request
 	req, err := jsb.makePrivateRequest("POST", jsb.urls.v1+url, bytes.NewReader(body))
@@ -154,40 +252,55 @@ func (jsb *Instance) CreateDocument(document types.CreateDocumentBody) (*types.N
 }
 
 // UploadDocument - uploads a json document
+// the file is read from document.FS when set, e.g. an embed.FS, otherwise from the local filesystem
 func (jsb *Instance) UploadDocument(document types.UploadDocumentBody) (*types.NewDocument, *RequestError) {
 	// project is required
 	if document.Project == "" {
 		return nil, &RequestError{"bad_request", "Project is required"}
 	}
 
-	// check if file exists
-	if _, err := os.Stat(document.FilePath); os.IsNotExist(err) {
-		return nil, &RequestError{"file_not_found", "File does not exist"}
+	// get content of file
+	var content []byte
+	var err error
+	if document.FS != nil {
+		content, err = fs.ReadFile(document.FS, document.FilePath)
+	} else {
+		content, err = os.ReadFile(document.FilePath)
 	}
 
-	// get content of file
-	content, err := os.ReadFile(document.FilePath)
-	if err != nil {
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &RequestError{"file_not_found", "File does not exist"}
+	} else if err != nil {
 		return nil, &RequestError{"invalid_file", "Could not read file"}
 	}
 
-	// check if content is a valid json string
-	if !IsValidJsonString(string(content)) {
-		return nil, &InvalidJsonError
+	// detect format if not set
+	if document.Format == "" {
+		document.Format = FormatFromPath(document.FilePath)
 	}
 
-	// set name if not set
+	// set name if not set, files in other formats are stored as .json documents
 	if document.Name == "" {
-		document.Name = filepath.Base(document.FilePath)
+		if document.FS != nil {
+			// paths of fs.FS are always slash separated
+			document.Name = path.Base(document.FilePath)
+		} else {
+			document.Name = filepath.Base(document.FilePath)
+		}
+
+		if document.Format != FormatJson {
+			document.Name = jsonName(document.Name)
+		}
 	}
 
 	// create document
-	return jsb.CreateDocument(types.CreateDocumentBody{
-		Project: document.Project,
-		Name:    document.Name,
-		Content: string(content),
-		Folder:  document.Folder,
-	})
+	return jsb.createDocument(types.CreateDocumentBody{
+		Project:       document.Project,
+		Name:          document.Name,
+		Folder:        document.Folder,
+		Format:        document.Format,
+		CreateFolders: document.CreateFolders,
+	}, content)
 }
 
 // CreateDocumentIfNotExists - creates a document if it does not exist
@@ -225,11 +338,53 @@ func (jsb *Instance) HasOwnDocument(idOrPath string) bool {
 
 // UpdateOwnDocument - Update document owned by the authenticated user
 func (jsb *Instance) UpdateOwnDocument(idOrPath string, content string) (*types.UpdatedDocument, *RequestError) {
-	// check if content is a valid json string
-	if !IsValidJsonString(content) {
-		return nil, &InvalidJsonError
+	return jsb.updateOwnDocument(idOrPath, content, nil)
+}
+
+// updateOwnDocument - Update document owned by the authenticated user with optional precondition headers
+func (jsb *Instance) updateOwnDocument(idOrPath string, content string, header http.Header) (*types.UpdatedDocument, *RequestError) {
+	// check if content is valid json
+	if err := jsb.validateContent([]byte(content)); err != nil {
+		return nil, err
 	}
 
+	// format content canonically if enabled
+	canonical, err := jsb.canonicalizeContent([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	content = string(canonical)
+
+	// skip updates that would not change the document
+	if jsb.config.Canonical.SkipUnchanged {
+		// a coalesced read could return the content from before a concurrent write
+		current, err := jsb.getOwnContentAsString(idOrPath, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if IsSameJson(current, content) {
+			return &types.UpdatedDocument{Changed: false}, nil
+		}
+	}
+
+	// check if content matches the schemas of the document
+	if jsb.hasSchemas() {
+		documentPath, err := jsb.ownDocumentPath(idOrPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := jsb.validateDocumentSchemas(documentPath, []byte(content)); err != nil {
+			return nil, err
+		}
+	}
+
+	return jsb.updateRawDocument(idOrPath, content, header)
+}
+
+// updateRawDocument - sends an update with json content as is, without validation or canonical formatting
+func (jsb *Instance) updateRawDocument(idOrPath string, content string, header http.Header) (*types.UpdatedDocument, *RequestError) {
 	body := JsonToReader(struct {
 		Content string `json:"content"`
 	}{
@@ -241,9 +396,19 @@ func (jsb *Instance) UpdateOwnDocument(idOrPath string, content string) (*types.
 		return nil, err
 	}
 
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
 	// send request
-	data, err := jsb.sendRequest(req)
+	res := &Response{}
+	data, err := jsb.WithResponse(res).sendRequest(req)
+	jsb.recordResponse(res)
 	if err != nil {
+		// precondition headers were rejected
+		if res.StatusCode == http.StatusPreconditionFailed {
+			return nil, &ConflictError
+		}
 		return nil, err
 	}
 
@@ -343,6 +508,44 @@ func (jsb *Instance) CreateFolderIfNotExists(body types.CreateFolderBody) (*type
 	return data, nil
 }
 
+// EnsureFolderPath - creates every missing folder of a path like "project/a/b/c" and returns the last folder
+// folders created concurrently by others are used as they are
+func (jsb *Instance) EnsureFolderPath(folderPath string) (*types.Folder, *RequestError) {
+	project, rest, _ := strings.Cut(strings.Trim(folderPath, "/"), "/")
+	if project == "" || rest == "" {
+		return nil, &RequestError{"bad_request", "Folder path must include a project and a folder"}
+	}
+
+	// most of the time the folder already exists
+	folder, err := jsb.GetFolder(project + "/" + rest)
+	if err == nil {
+		return folder, nil
+	} else if err.Code != "notFound" {
+		return nil, err
+	}
+
+	parent := ""
+	for _, name := range strings.Split(rest, "/") {
+		if name == "" {
+			continue
+		}
+
+		created, err := jsb.CreateFolderIfNotExists(types.CreateFolderBody{
+			Name:    name,
+			Project: project,
+			Folder:  parent,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		folder = &created.Folder
+		parent = created.Path
+	}
+
+	return folder, nil
+}
+
 // getFolder - gets a folder
 func (jsb *Instance) getFolder(idOrPath string, includeStats bool) (*types.Folder, *RequestError) {
 	url := fmt.Sprintf("/folder/%s", idOrPath)
@@ -359,7 +562,7 @@ func (jsb *Instance) getFolder(idOrPath string, includeStats bool) (*types.Folde
 	}
 
 	// send request
-	data, err := jsb.sendRequest(req)
+	data, err := jsb.sendCoalescedRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -367,24 +570,9 @@ func (jsb *Instance) getFolder(idOrPath string, includeStats bool) (*types.Folde
 	// convert to map
 	d := data.(map[string]interface{})
 
-	f := &types.Folder{
-		Id:        d["id"].(string),
-		Name:      d["name"].(string),
-		Path:      d["path"].(string),
-		Project:   d["project"].(string),
-		CreatedAt: d["createdAt"].(string),
-		UpdatedAt: d["updatedAt"].(string),
-	}
-
-	if includeStats {
-		stats := d["stats"].(map[string]interface{})
-
-		if stats != nil {
-			f.Stats = &types.FolderStats{
-				Documents: stats["documents"].(float64),
-				Folders:   stats["folders"].(float64),
-			}
-		}
+	f := types.DataToFolder(d)
+	if !includeStats {
+		f.Stats = nil
 	}
 
 	return f, nil
@@ -2,12 +2,17 @@ package jsonbank
 
 import (
 	"github.com/jsonbankio/go-sdk/types"
+	"sync"
 )
 
 type Instance struct {
-	config Config         // Instance Config
-	memory map[string]any // Instance memory
-	urls   struct {
+	config      Config          // Instance Config
+	memory      map[string]any  // Instance memory
+	flights     *flightGroup    // In-flight reads shared by concurrent callers
+	response    *Response       // Where to record response metadata, see WithResponse
+	unsupported *sync.Map       // Optional endpoints the server does not support
+	schemas     *schemaRegistry // Schemas validated before writes, see AddSchema
+	urls        struct {
 		v1     string // v1 url
 		public string // public url
 	}
@@ -28,7 +33,7 @@ func (jsb *Instance) GetContent(idOrPath string) (any, *RequestError) {
 	}
 
 	// send request
-	data, err := jsb.sendRequest(req)
+	data, err := jsb.sendCoalescedRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -44,7 +49,7 @@ func (jsb *Instance) GetContentAsString(idOrPath string) (string, *RequestError)
 	}
 
 	// send request
-	data, err := jsb.sendRequestAsText(req)
+	data, err := jsb.sendCoalescedRequestAsText(req)
 
 	if err != nil {
 		return "", err
@@ -61,7 +66,7 @@ func (jsb *Instance) GetDocumentMeta(idOrPath string) (*types.DocumentMeta, *Req
 	}
 
 	// send request
-	d, err := jsb.sendRequest(req)
+	d, err := jsb.sendCoalescedRequest(req)
 	if err != nil {
 		return nil, err
 	}
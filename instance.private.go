@@ -1,10 +1,12 @@
 package jsonbank
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"net/http"
 	"reflect"
+	"time"
 )
 
 type Keys struct {
@@ -13,8 +15,52 @@ type Keys struct {
 }
 
 type Config struct {
-	Host string // Server Host
-	Keys Keys   // Keys
+	Host        string      // Server Host
+	Keys        Keys        // Keys
+	Compression Compression // Gzip compression, disabled by default
+	Canonical   Canonical   // Canonical formatting of written content, disabled by default
+	UseNumber   bool        // Decode numbers in content as json.Number instead of float64 to keep their precision
+	Validation  Validation  // Strict validation of written content, disabled by default
+}
+
+// createDocumentRequest - request body sent by CreateDocument
+type createDocumentRequest struct {
+	Name    string   `json:"name"`
+	Project string   `json:"project"`
+	Folder  string   `json:"folder"`
+	Content jsonText `json:"content"`
+}
+
+// jsonText - json content encoded as a json string without converting it to a Go string first
+type jsonText []byte
+
+// MarshalJSON - quotes and escapes the content
+func (t jsonText) MarshalJSON() ([]byte, error) {
+	const hex = "0123456789abcdef"
+
+	buf := make([]byte, 0, len(t)+2)
+	buf = append(buf, '"')
+	for _, c := range t {
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			if c < 0x20 {
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+	}
+	buf = append(buf, '"')
+
+	return buf, nil
 }
 
 // ========== Private Methods ==========
@@ -67,16 +113,42 @@ func (jsb *Instance) makePrivateRequest(method string, url string, data io.Reade
 	return req, nil
 }
 
-func (jsb *Instance) sendRequest(req *http.Request) (any, *RequestError) {
-	// make request
+// do - sends the request and records the response metadata
+func (jsb *Instance) do(req *http.Request) (*http.Response, *RequestError) {
+	if err := jsb.compressRequest(req); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, &RequestError{"request_error", err.Error()}
 	}
 
+	jsb.recordResponse(newResponse(res, time.Since(start)))
+
+	if err := decompressResponse(res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (jsb *Instance) sendRequest(req *http.Request) (any, *RequestError) {
+	// make request
+	res, err := jsb.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
 	// convert response to json
 	var data map[string]any
-	jsonError := json.NewDecoder(res.Body).Decode(&data)
+	decoder := json.NewDecoder(res.Body)
+	if jsb.config.UseNumber {
+		decoder.UseNumber()
+	}
+	jsonError := decoder.Decode(&data)
 
 	if jsonError != nil {
 		return nil, &RequestError{"json_error", jsonError.Error()}
@@ -84,35 +156,133 @@ func (jsb *Instance) sendRequest(req *http.Request) (any, *RequestError) {
 
 	// check if request was successful
 	if res.StatusCode != 200 {
-		if data["error"] != nil {
-			dataError := data["error"]
-			// check if dataError is a map
-			if reflect.TypeOf(dataError).Kind() == reflect.String {
-				return nil, &RequestError{"request_error", dataError.(string)}
-			} else if reflect.TypeOf(dataError).Kind() == reflect.Map {
-				dataError := dataError.(map[string]any)
-				return nil, &RequestError{dataError["code"].(string), dataError["message"].(string)}
-			} else {
-				return nil, &RequestError{"request_error", "Request was not successful"}
-			}
+		return nil, responseError(data)
+	}
+
+	return data, nil
+}
+
+// responseError - converts the body of an unsuccessful response to a RequestError
+func responseError(data map[string]any) *RequestError {
+	if data["error"] != nil {
+		dataError := data["error"]
+		// check if dataError is a map
+		if reflect.TypeOf(dataError).Kind() == reflect.String {
+			return &RequestError{"request_error", dataError.(string)}
+		} else if reflect.TypeOf(dataError).Kind() == reflect.Map {
+			dataError := dataError.(map[string]any)
+			return &RequestError{dataError["code"].(string), dataError["message"].(string)}
 		} else {
-			return nil, &RequestError{"request_error", "Request was not successful"}
+			return &RequestError{"request_error", "Request was not successful"}
 		}
+	} else {
+		return &RequestError{"request_error", "Request was not successful"}
 	}
+}
 
-	return data, nil
+// sendOptionalRequest - sends a request to an endpoint that the server may not support
+// when the server does not know the endpoint, supported is false and the feature is not requested again
+// so that callers can fall back to client-side orchestration
+func (jsb *Instance) sendOptionalRequest(feature string, req *http.Request) (data any, supported bool, err *RequestError) {
+	if jsb.unsupported != nil {
+		if _, ok := jsb.unsupported.Load(feature); ok {
+			return nil, false, nil
+		}
+	}
+
+	res := &Response{}
+	data, err = jsb.WithResponse(res).sendRequest(req)
+	jsb.recordResponse(res)
+
+	if err != nil {
+		// unknown routes respond with 404 while missing documents respond with a notFound error code
+		switch res.StatusCode {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			if err.Code != "notFound" {
+				if jsb.unsupported != nil {
+					jsb.unsupported.Store(feature, true)
+				}
+				return nil, false, nil
+			}
+		}
+
+		return nil, true, err
+	}
+
+	return data, true, nil
 }
 
-// sendRequestAsText - send request and return response as text
-func (jsb *Instance) sendRequestAsText(req *http.Request) (*string, *RequestError) {
+// decodeContent - decodes json content, numbers are decoded as configured by UseNumber
+func (jsb *Instance) decodeContent(content []byte) (any, *RequestError) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if jsb.config.UseNumber {
+		decoder.UseNumber()
+	}
+
+	if err := decoder.Decode(&value); err != nil {
+		return nil, &RequestError{"json_error", err.Error()}
+	}
+
+	return value, nil
+}
+
+// flightResult - result of a coalesced request, including the response metadata shared with every caller
+type flightResult struct {
+	data     any
+	response *Response
+}
+
+// coalesce - runs send once for concurrent identical requests
+func (jsb *Instance) coalesce(key string, send func(jsb *Instance) (any, *RequestError)) (any, *RequestError) {
+	result, err := jsb.flights.do(key, func() (any, *RequestError) {
+		// record the response of the shared request so that every caller receives it
+		res := &Response{}
+		data, err := send(jsb.WithResponse(res))
+		return flightResult{data, res}, err
+	})
+
+	r := result.(flightResult)
+	if r.response.StatusCode != 0 {
+		jsb.recordResponse(r.response)
+	}
+
+	// every caller gets its own copy of decoded json, so that callers can modify it safely
+	return copyJsonValue(r.data), err
+}
+
+// sendCoalescedRequest - same as sendRequest, but concurrent identical requests share one round trip
+func (jsb *Instance) sendCoalescedRequest(req *http.Request) (any, *RequestError) {
+	return jsb.coalesce("json "+req.Method+" "+req.URL.String(), func(jsb *Instance) (any, *RequestError) {
+		return jsb.sendRequest(req)
+	})
+}
+
+// sendCoalescedRequestAsText - same as sendRequestAsText, but concurrent identical requests share one round trip
+func (jsb *Instance) sendCoalescedRequestAsText(req *http.Request) (*string, *RequestError) {
+	data, err := jsb.coalesce("text "+req.Method+" "+req.URL.String(), func(jsb *Instance) (any, *RequestError) {
+		return jsb.sendRequestAsText(req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data.(*string), nil
+}
+
+// openRequest - send request and return the response of a successful request
+// the caller is responsible for closing the response body
+func (jsb *Instance) openRequest(req *http.Request) (*http.Response, *RequestError) {
 	// make request
-	res, err := http.DefaultClient.Do(req)
+	res, err := jsb.do(req)
 	if err != nil {
-		return nil, &RequestError{"request_error", err.Error()}
+		return nil, err
 	}
 
 	// check if request was successful
 	if res.StatusCode != 200 {
+		defer res.Body.Close()
+
 		// convert response to json
 		var data map[string]any
 		jsonError := json.NewDecoder(res.Body).Decode(&data)
@@ -121,21 +291,20 @@ func (jsb *Instance) sendRequestAsText(req *http.Request) (*string, *RequestErro
 			return nil, &RequestError{"json_error", jsonError.Error()}
 		}
 
-		if data["error"] != nil {
-			dataError := data["error"]
-			// check if dataError is a map
-			if reflect.TypeOf(dataError).Kind() == reflect.String {
-				return nil, &RequestError{"request_error", dataError.(string)}
-			} else if reflect.TypeOf(dataError).Kind() == reflect.Map {
-				dataError := dataError.(map[string]any)
-				return nil, &RequestError{dataError["code"].(string), dataError["message"].(string)}
-			} else {
-				return nil, &RequestError{"request_error", "Request was not successful"}
-			}
-		} else {
-			return nil, &RequestError{"request_error", "Request was not successful"}
-		}
+		return nil, responseError(data)
+	}
+
+	return res, nil
+}
+
+// sendRequestAsText - send request and return response as text
+func (jsb *Instance) sendRequestAsText(req *http.Request) (*string, *RequestError) {
+	// make request
+	res, reqErr := jsb.openRequest(req)
+	if reqErr != nil {
+		return nil, reqErr
 	}
+	defer res.Body.Close()
 
 	// convert res.Body to string
 	bodyBytes, err := io.ReadAll(res.Body)
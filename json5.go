@@ -0,0 +1,504 @@
+package jsonbank
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// json5Converter - converts JSONC or JSON5 content to strict json while reading it
+// numbers keep their text and object keys keep their order
+type json5Converter struct {
+	src   []byte
+	pos   int
+	json5 bool // accept JSON5 syntax, otherwise only comments and trailing commas
+	out   bytes.Buffer
+}
+
+// json5Error - a conversion problem at an offset of the source
+type json5Error struct {
+	offset  int
+	message string
+}
+
+// convertJson5 - converts JSONC (json5 false) or JSON5 content to strict json
+func convertJson5(content []byte, json5 bool) ([]byte, *RequestError) {
+	c := &json5Converter{src: content, json5: json5}
+
+	err := c.convert()
+	if err != nil {
+		format := FormatJsonc
+		if json5 {
+			format = FormatJson5
+		}
+
+		line, column := position(content, int64(err.offset))
+		return nil, &RequestError{"invalid_" + format, fmt.Sprintf("line %v, column %v: %v", line, column, err.message)}
+	}
+
+	return c.out.Bytes(), nil
+}
+
+// convert - converts the whole document
+func (c *json5Converter) convert() *json5Error {
+	// byte order mark
+	if bytes.HasPrefix(c.src, []byte("\uFEFF")) {
+		c.pos += 3
+	}
+
+	if err := c.skip(); err != nil {
+		return err
+	}
+
+	if c.pos == len(c.src) {
+		return c.fail("unexpected end of content")
+	}
+
+	if err := c.value(); err != nil {
+		return err
+	}
+
+	if err := c.skip(); err != nil {
+		return err
+	}
+
+	if c.pos < len(c.src) {
+		return c.fail("unexpected content after the document")
+	}
+
+	return nil
+}
+
+// fail - returns an error at the current position
+func (c *json5Converter) fail(format string, args ...any) *json5Error {
+	return &json5Error{c.pos, fmt.Sprintf(format, args...)}
+}
+
+// peek - returns the current byte, 0 at the end of content
+func (c *json5Converter) peek() byte {
+	if c.pos < len(c.src) {
+		return c.src[c.pos]
+	}
+	return 0
+}
+
+// skip - skips whitespace and comments
+func (c *json5Converter) skip() *json5Error {
+	for c.pos < len(c.src) {
+		switch ch := c.src[c.pos]; {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			c.pos++
+		case bytes.HasPrefix(c.src[c.pos:], []byte("//")):
+			end := bytes.IndexAny(c.src[c.pos:], "\r\n")
+			if end < 0 {
+				c.pos = len(c.src)
+			} else {
+				c.pos += end
+			}
+		case bytes.HasPrefix(c.src[c.pos:], []byte("/*")):
+			end := bytes.Index(c.src[c.pos+2:], []byte("*/"))
+			if end < 0 {
+				return c.fail("unterminated comment")
+			}
+			c.pos += end + 4
+		case c.json5 && ch >= utf8.RuneSelf:
+			// JSON5 accepts every unicode space separator as whitespace
+			r, size := utf8.DecodeRune(c.src[c.pos:])
+			if !unicode.Is(unicode.Zs, r) && r != '\uFEFF' && r != '\u2028' && r != '\u2029' {
+				return nil
+			}
+			c.pos += size
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// value - converts any value
+func (c *json5Converter) value() *json5Error {
+	switch ch := c.peek(); {
+	case ch == '{':
+		return c.object()
+	case ch == '[':
+		return c.array()
+	case ch == '"' || (ch == '\'' && c.json5):
+		return c.string()
+	case ch == '-' || ch == '+' || ch == '.' || (ch >= '0' && ch <= '9'):
+		return c.number()
+	case ch == 0:
+		return c.fail("unexpected end of content")
+	}
+
+	word := c.identifier()
+	switch word {
+	case "true", "false", "null":
+		c.out.WriteString(word)
+		c.pos += len(word)
+		return nil
+	case "Infinity", "NaN":
+		if c.json5 {
+			return c.fail("%v cannot be represented in json", word)
+		}
+	}
+
+	r, _ := utf8.DecodeRune(c.src[c.pos:])
+	return c.fail("unexpected character %q", r)
+}
+
+// identifier - returns the identifier starting at the current position without consuming it
+func (c *json5Converter) identifier() string {
+	end := c.pos
+	for end < len(c.src) {
+		r, size := utf8.DecodeRune(c.src[end:])
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || (end > c.pos && unicode.IsDigit(r))) {
+			break
+		}
+		end += size
+	}
+
+	return string(c.src[c.pos:end])
+}
+
+// object - converts an object
+func (c *json5Converter) object() *json5Error {
+	c.pos++
+	c.out.WriteByte('{')
+
+	for first := true; ; first = false {
+		if err := c.skip(); err != nil {
+			return err
+		}
+
+		if c.peek() == '}' {
+			c.pos++
+			c.out.WriteByte('}')
+			return nil
+		}
+
+		if !first {
+			c.out.WriteByte(',')
+		}
+
+		if err := c.key(); err != nil {
+			return err
+		}
+
+		if err := c.skip(); err != nil {
+			return err
+		}
+
+		if c.peek() != ':' {
+			return c.fail("expected ':' after object key")
+		}
+		c.pos++
+		c.out.WriteByte(':')
+
+		if err := c.skip(); err != nil {
+			return err
+		}
+
+		if err := c.value(); err != nil {
+			return err
+		}
+
+		if err := c.skip(); err != nil {
+			return err
+		}
+
+		switch c.peek() {
+		case ',':
+			c.pos++
+		case '}':
+		default:
+			return c.fail("expected ',' or '}' after object value")
+		}
+	}
+}
+
+// key - converts an object key, JSON5 keys may be identifiers
+func (c *json5Converter) key() *json5Error {
+	ch := c.peek()
+	if ch == '"' || (ch == '\'' && c.json5) {
+		return c.string()
+	}
+
+	if c.json5 {
+		if name := c.identifier(); name != "" {
+			c.pos += len(name)
+			writeCanonicalString(&c.out, name)
+			return nil
+		}
+	}
+
+	if ch == 0 {
+		return c.fail("unexpected end of content")
+	}
+
+	return c.fail("expected object key")
+}
+
+// array - converts an array
+func (c *json5Converter) array() *json5Error {
+	c.pos++
+	c.out.WriteByte('[')
+
+	for first := true; ; first = false {
+		if err := c.skip(); err != nil {
+			return err
+		}
+
+		if c.peek() == ']' {
+			c.pos++
+			c.out.WriteByte(']')
+			return nil
+		}
+
+		if !first {
+			c.out.WriteByte(',')
+		}
+
+		if err := c.value(); err != nil {
+			return err
+		}
+
+		if err := c.skip(); err != nil {
+			return err
+		}
+
+		switch c.peek() {
+		case ',':
+			c.pos++
+		case ']':
+		default:
+			return c.fail("expected ',' or ']' after array element")
+		}
+	}
+}
+
+// string - converts a double or single quoted string
+func (c *json5Converter) string() *json5Error {
+	quote := c.src[c.pos]
+	start := c.pos
+	c.pos++
+	c.out.WriteByte('"')
+
+	for {
+		if c.pos >= len(c.src) {
+			c.pos = start
+			return c.fail("unterminated string")
+		}
+
+		ch := c.src[c.pos]
+		switch {
+		case ch == quote:
+			c.pos++
+			c.out.WriteByte('"')
+			return nil
+		case ch == '"':
+			// double quote inside a single quoted string
+			c.pos++
+			c.out.WriteString(`\"`)
+		case ch == '\n' || ch == '\r':
+			return c.fail("unterminated string")
+		case ch < 0x20:
+			return c.fail("control character in string")
+		case ch == '\\':
+			if err := c.escape(); err != nil {
+				return err
+			}
+		default:
+			c.pos++
+			c.out.WriteByte(ch)
+		}
+	}
+}
+
+// escape - converts an escape sequence in a string
+func (c *json5Converter) escape() *json5Error {
+	if c.pos+1 >= len(c.src) {
+		return c.fail("unterminated string")
+	}
+
+	ch := c.src[c.pos+1]
+	switch ch {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		c.out.Write(c.src[c.pos : c.pos+2])
+		c.pos += 2
+		return nil
+	case 'u':
+		if !isHex(c.src[c.pos+2:], 4) {
+			return c.fail("invalid unicode escape")
+		}
+		c.out.Write(c.src[c.pos : c.pos+6])
+		c.pos += 6
+		return nil
+	}
+
+	if !c.json5 {
+		return c.fail("invalid escape sequence")
+	}
+
+	switch {
+	case ch == '\'':
+		c.out.WriteByte('\'')
+	case ch == 'v':
+		c.out.WriteString(`\u000b`)
+	case ch == '0' && !(c.pos+2 < len(c.src) && c.src[c.pos+2] >= '0' && c.src[c.pos+2] <= '9'):
+		c.out.WriteString(`\u0000`)
+	case ch >= '0' && ch <= '9':
+		return c.fail("invalid escape sequence")
+	case ch == 'x':
+		if !isHex(c.src[c.pos+2:], 2) {
+			return c.fail("invalid hex escape")
+		}
+		c.out.WriteString(`\u00`)
+		c.out.Write(c.src[c.pos+2 : c.pos+4])
+		c.pos += 4
+		return nil
+	case ch == '\r':
+		// line continuation
+		if c.pos+2 < len(c.src) && c.src[c.pos+2] == '\n' {
+			c.pos++
+		}
+	case ch == '\n':
+	case ch >= utf8.RuneSelf:
+		r, size := utf8.DecodeRune(c.src[c.pos+1:])
+		if r != '\u2028' && r != '\u2029' {
+			c.out.WriteRune(r)
+		}
+		c.pos += 1 + size
+		return nil
+	case ch < 0x20:
+		return c.fail("control character in string")
+	default:
+		// any other character escapes itself
+		c.out.WriteByte(ch)
+	}
+
+	c.pos += 2
+	return nil
+}
+
+// isHex - checks if s starts with n hex digits
+func isHex(s []byte, n int) bool {
+	if len(s) < n {
+		return false
+	}
+
+	for _, ch := range s[:n] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", rune(ch)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// number - converts a number, JSON5 numbers may be hexadecimal, have a leading + or omit digits around the point
+func (c *json5Converter) number() *json5Error {
+	start := c.pos
+	sign := ""
+
+	switch c.peek() {
+	case '-':
+		sign = "-"
+		c.pos++
+	case '+':
+		if !c.json5 {
+			return c.fail("unexpected character '+'")
+		}
+		c.pos++
+	}
+
+	if c.json5 {
+		switch word := c.identifier(); word {
+		case "Infinity", "NaN":
+			c.pos = start
+			return c.fail("%v cannot be represented in json", word)
+		}
+
+		// hexadecimal
+		if rest := c.src[c.pos:]; bytes.HasPrefix(rest, []byte("0x")) || bytes.HasPrefix(rest, []byte("0X")) {
+			end := c.pos + 2
+			for end < len(c.src) && isHex(c.src[end:], 1) {
+				end++
+			}
+
+			n, ok := new(big.Int).SetString(string(c.src[c.pos+2:end]), 16)
+			if !ok {
+				return c.fail("invalid hexadecimal number")
+			}
+
+			if sign == "" || n.Sign() == 0 {
+				sign = ""
+			}
+			c.out.WriteString(sign + n.String())
+			c.pos = end
+			return nil
+		}
+	}
+
+	digits := func() string {
+		begin := c.pos
+		for c.pos < len(c.src) && c.src[c.pos] >= '0' && c.src[c.pos] <= '9' {
+			c.pos++
+		}
+		return string(c.src[begin:c.pos])
+	}
+
+	integer := digits()
+	if len(integer) > 1 && integer[0] == '0' {
+		c.pos = start
+		return c.fail("numbers cannot have leading zeros")
+	}
+
+	var fraction string
+	hasPoint := c.peek() == '.'
+	if hasPoint {
+		c.pos++
+		fraction = digits()
+	}
+
+	if integer == "" && fraction == "" {
+		c.pos = start
+		return c.fail("invalid number")
+	}
+
+	if !c.json5 && (integer == "" || (hasPoint && fraction == "")) {
+		c.pos = start
+		return c.fail("invalid number")
+	}
+
+	var exponent string
+	if ch := c.peek(); ch == 'e' || ch == 'E' {
+		c.pos++
+		expSign := ""
+		if ch := c.peek(); ch == '+' || ch == '-' {
+			expSign = string(ch)
+			c.pos++
+		}
+
+		expDigits := digits()
+		if expDigits == "" {
+			return c.fail("invalid number exponent")
+		}
+		exponent = "e" + expSign + expDigits
+	}
+
+	if integer == "" {
+		integer = "0"
+	}
+
+	c.out.WriteString(sign + integer)
+	if fraction != "" {
+		c.out.WriteString("." + fraction)
+	}
+	c.out.WriteString(exponent)
+
+	return nil
+}
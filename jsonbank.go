@@ -1,5 +1,7 @@
 package jsonbank
 
+import "sync"
+
 // Init - initializes the jsonbank instance
 func Init(config Config) Instance {
 	// Validate config
@@ -16,6 +18,12 @@ func Init(config Config) Instance {
 	jsb.SetHost(config.Host)
 	// set memory
 	jsb.memory = make(map[string]any)
+	// set in-flight request group
+	jsb.flights = &flightGroup{}
+	// set unsupported endpoints
+	jsb.unsupported = &sync.Map{}
+	// set schemas
+	jsb.schemas = &schemaRegistry{}
 
 	return jsb
 }
@@ -1,13 +1,28 @@
 package jsonbank
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/jsonbankio/go-sdk/types"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 type TestFile struct {
@@ -328,6 +343,39 @@ func TestAuthenticated(t *testing.T) {
 		_, _ = jsb.UpdateOwnDocument(testFile.Id, testFileContent)
 	})
 
+	t.Run("PatchOwnDocument", func(t *testing.T) {
+		res, err := jsb.PatchOwnDocument(testFile.Id, []types.PatchOperation{
+			{Op: "test", Path: "/author", Value: "jsonbank"},
+			{Op: "add", Path: "/patched", Value: true},
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if res.Changed != true {
+			t.Error("Document was not patched")
+		}
+
+		// revert changes
+		_, _ = jsb.UpdateOwnDocument(testFile.Id, testFileContent)
+	})
+
+	t.Run("MergeOwnDocument", func(t *testing.T) {
+		merged, res, err := jsb.MergeOwnDocument(testFile.Id, map[string]any{"merged": true})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if res.Changed != true || merged.(map[string]interface{})["author"] != "jsonbank" {
+			t.Error("Document was not merged")
+		}
+
+		// revert changes, null deletes the key
+		_, _, _ = jsb.MergeOwnDocument(testFile.Id, map[string]any{"merged": nil})
+	})
+
 	t.Run("CreateFolder", func(t *testing.T) {
 		folder, err := jsb.CreateFolder(types.CreateFolderBody{
 			Name:    "folder",
@@ -450,3 +498,2430 @@ func TestAuthenticated(t *testing.T) {
 		}
 	})
 }
+
+func TestRequestCoalescing(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		// keep the request in flight long enough for every caller to join it
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte(testFileContent))
+	}))
+	defer server.Close()
+
+	var jsb = InitWithoutKeys()
+	jsb.SetHost(server.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			content, err := jsb.GetContent("jsonbank/sdk-test/index.json")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			data := content.(map[string]interface{})
+			if data["author"] != "jsonbank" {
+				t.Error("Content does not match")
+			}
+
+			// every caller owns its copy
+			data["author"] = "changed"
+		}()
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&hits) != 1 {
+		t.Errorf("Expected 1 request, got %v", hits)
+	}
+
+	// requests after the first one completed are not coalesced
+	_, _ = jsb.GetContent("jsonbank/sdk-test/index.json")
+	if atomic.LoadInt32(&hits) != 2 {
+		t.Errorf("Expected 2 requests, got %v", hits)
+	}
+}
+
+func TestWithResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		if strings.HasSuffix(r.URL.Path, "missing.json") {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"code": "notFound", "message": "Document not found"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(testFileContent))
+	}))
+	defer server.Close()
+
+	var jsb = InitWithoutKeys()
+	jsb.SetHost(server.URL)
+
+	var res Response
+	_, err := jsb.WithResponse(&res).GetContent("jsonbank/sdk-test/index.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if res.StatusCode != 200 || res.RequestId != "req-1" || res.RateLimitRemaining != 99 {
+		t.Errorf("Unexpected response metadata: %+v", res)
+	}
+
+	// metadata is recorded for failed requests too
+	_, err = jsb.WithResponse(&res).GetContentAsString("jsonbank/sdk-test/missing.json")
+	if err == nil || err.Code != "notFound" {
+		t.Errorf("Expected notFound error, got %v", err)
+	}
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %v", res.StatusCode)
+	}
+}
+
+func TestContentStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := os.ReadFile("./tests/upload.json")
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	var jsb = InitWithoutKeys()
+	jsb.SetHost(server.URL)
+
+	stream, err := jsb.GetContentStream("jsonbank/sdk-test/upload.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer stream.Close()
+
+	count := 0
+	elements := stream.Elements()
+	for elements.Next() {
+		var movie struct {
+			Title string
+		}
+
+		if err := elements.Decode(&movie); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if movie.Title == "" {
+			t.Errorf("Element %v has no title", elements.Index())
+		}
+		count++
+	}
+
+	if elements.Err() != nil {
+		t.Error(elements.Err())
+	}
+
+	if count == 0 {
+		t.Error("Stream returned no elements")
+	}
+}
+
+func TestCreateDocumentFrom(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body types.CreateDocumentBody
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body.Content)
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":        "id",
+			"name":      body.Name,
+			"path":      body.Name,
+			"project":   body.Project,
+			"createdAt": "2022-01-01T00:00:00.000Z",
+		})
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+	document := types.CreateDocumentBody{Name: "index.json", Project: "sdk-test"}
+
+	t.Run("CreateDocumentFrom", func(t *testing.T) {
+		_, err := jsb.CreateDocumentFrom(document, strings.NewReader(testFileContent))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if received[len(received)-1] != testFileContent {
+			t.Error("Content does not match")
+		}
+
+		_, err = jsb.CreateDocumentFrom(document, strings.NewReader(`{"invalid": }`))
+		if err == nil || err.Code != InvalidJsonError.Code {
+			t.Error("CreateDocumentFrom should reject invalid json")
+		}
+	})
+
+	t.Run("CreateDocumentFromValue", func(t *testing.T) {
+		_, err := jsb.CreateDocumentFromValue(document, struct {
+			Name   string `json:"name"`
+			Author string `json:"author"`
+		}{"JsonBank SDK Test File", "jsonbank"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if received[len(received)-1] != `{"name":"JsonBank SDK Test File","author":"jsonbank"}` {
+			t.Error("Content does not match")
+		}
+	})
+
+	t.Run("UploadDocument From FS", func(t *testing.T) {
+		fileSystem := fstest.MapFS{"configs/app.json": {Data: []byte(testFileContent)}}
+		document, err := jsb.UploadDocument(types.UploadDocumentBody{
+			FS:       fileSystem,
+			FilePath: "configs/app.json",
+			Project:  "sdk-test",
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if document.Name != "app.json" || received[len(received)-1] != testFileContent {
+			t.Error("Uploaded document does not match")
+		}
+
+		_, err = jsb.UploadDocument(types.UploadDocumentBody{FS: fileSystem, FilePath: "missing.json", Project: "sdk-test"})
+		if err == nil || err.Code != "file_not_found" {
+			t.Error("UploadDocument should fail for missing files")
+		}
+	})
+}
+
+func TestCompression(t *testing.T) {
+	var compressed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		compressed = r.Header.Get("Content-Encoding") == "gzip"
+		if compressed {
+			body, _ = gzip.NewReader(r.Body)
+		}
+
+		var document types.CreateDocumentBody
+		if err := json.NewDecoder(body).Decode(&document); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "invalid body"}`))
+			return
+		}
+
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "gzip not accepted"}`))
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		_ = json.NewEncoder(writer).Encode(map[string]any{
+			"id":        "id",
+			"name":      document.Name,
+			"path":      document.Name,
+			"project":   document.Project,
+			"createdAt": "2022-01-01T00:00:00.000Z",
+		})
+		_ = writer.Close()
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{
+		Host:        server.URL,
+		Keys:        Keys{Public: "public", Private: "private"},
+		Compression: Compression{Enabled: true},
+	})
+
+	// small payloads are not compressed
+	_, err := jsb.CreateDocument(types.CreateDocumentBody{Name: "index.json", Project: "sdk-test", Content: testFileContent})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if compressed {
+		t.Error("Small payload should not be compressed")
+	}
+
+	document, err := jsb.UploadDocument(types.UploadDocumentBody{FilePath: "./tests/upload.json", Project: "sdk-test"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !compressed {
+		t.Error("Large payload should be compressed")
+	}
+
+	if document.Name != "upload.json" {
+		t.Error("Document name mismatch")
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		document   string
+		operations string
+		expected   string
+		errorCode  string
+	}{
+		{"add", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"baz": "qux", "foo": "bar"}`, ""},
+		{"add to array", `{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo": ["bar", "qux", "baz"]}`, ""},
+		{"append to array", `{"foo": [1]}`, `[{"op": "add", "path": "/foo/-", "value": 2}]`, `{"foo": [1, 2]}`, ""},
+		{"remove", `{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo": "bar"}`, ""},
+		{"remove from array", `{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo": ["bar", "baz"]}`, ""},
+		{"replace", `{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz": "boo", "foo": "bar"}`, ""},
+		{"move", `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`, `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`, `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`, ""},
+		{"move in array", `{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, `{"foo": ["all", "cows", "eat", "grass"]}`, ""},
+		{"copy", `{"a": {"b": 1}}`, `[{"op": "copy", "from": "/a", "path": "/c"}]`, `{"a": {"b": 1}, "c": {"b": 1}}`, ""},
+		{"escaped pointer", `{"a/b": {"m~n": 1}}`, `[{"op": "replace", "path": "/a~1b/m~0n", "value": 2}]`, `{"a/b": {"m~n": 2}}`, ""},
+		{"test", `{"baz": "qux", "foo": ["a", 2, "c"]}`, `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`, `{"baz": "qux", "foo": ["a", 2, "c"]}`, ""},
+		{"failed test", `{"baz": "qux"}`, `[{"op": "test", "path": "/baz", "value": "bar"}]`, "", "patch_test_failed"},
+		{"missing parent", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`, "", "invalid_patch"},
+		{"replace missing", `{"foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "qux"}]`, "", "invalid_patch"},
+		{"index out of bounds", `{"foo": [1]}`, `[{"op": "add", "path": "/foo/3", "value": 2}]`, "", "invalid_patch"},
+		{"move into child", `{"foo": {"bar": 1}}`, `[{"op": "move", "from": "/foo", "path": "/foo/bar/baz"}]`, "", "invalid_patch"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var document any
+			var operations []types.PatchOperation
+			_ = json.Unmarshal([]byte(test.document), &document)
+			_ = json.Unmarshal([]byte(test.operations), &operations)
+
+			result, err := ApplyPatch(document, operations)
+			if test.errorCode != "" {
+				if err == nil || err.Code != test.errorCode {
+					t.Errorf("Expected %v error, got %v", test.errorCode, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			var expected any
+			_ = json.Unmarshal([]byte(test.expected), &expected)
+			if !valuesEqual(result, expected) {
+				t.Errorf("Expected %v, got %v", expected, result)
+			}
+		})
+	}
+
+	// the original document is not modified
+	document := map[string]any{"foo": "bar"}
+	_, _ = ApplyPatch(document, []types.PatchOperation{{Op: "remove", Path: "/foo"}})
+	if document["foo"] != "bar" {
+		t.Error("ApplyPatch modified the original document")
+	}
+}
+
+func TestPatchOwnDocumentFallback(t *testing.T) {
+	var content = testFileContent
+	var patchRequests int
+	var concurrentWrite bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PATCH":
+			// servers without patch support
+			patchRequests++
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("Not Found"))
+		case "GET":
+			_, _ = w.Write([]byte(content))
+			// another writer changes the document right after it was read
+			if concurrentWrite {
+				concurrentWrite = false
+				content = `{"author":"someone else"}`
+			}
+		case "POST":
+			var body struct {
+				Content string `json:"content"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			changed := body.Content != content
+			content = body.Content
+			_ = json.NewEncoder(w).Encode(map[string]any{"changed": changed})
+		}
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+
+	for i := 0; i < 2; i++ {
+		res, err := jsb.PatchOwnDocument("sdk-test/index.json", []types.PatchOperation{
+			{Op: "test", Path: "/author", Value: "jsonbank"},
+			{Op: "add", Path: "/updated", Value: true},
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if res.Changed != (i == 0) {
+			t.Errorf("Expected changed to be %v", i == 0)
+		}
+	}
+
+	if !strings.Contains(content, `"updated":true`) {
+		t.Error("Document was not patched")
+	}
+
+	// unsupported endpoints are not requested again
+	if patchRequests != 1 {
+		t.Errorf("Expected 1 patch request, got %v", patchRequests)
+	}
+
+	// changes made by other writers are not overwritten
+	concurrentWrite = true
+	_, err := jsb.PatchOwnDocument("sdk-test/index.json", []types.PatchOperation{{Op: "add", Path: "/lost", Value: true}})
+	if err == nil || err.Code != ConflictError.Code || content != `{"author":"someone else"}` {
+		t.Errorf("Expected conflict error, got %v %v", err, content)
+	}
+}
+
+func TestPatchOwnDocumentWithSchemas(t *testing.T) {
+	var patchRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PATCH":
+			atomic.AddInt32(&patchRequests, 1)
+			_ = json.NewEncoder(w).Encode(map[string]any{"changed": true})
+		case "GET":
+			_, _ = w.Write([]byte(testFileContent))
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"changed": true})
+		}
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+	schema, _ := CompileSchema(`{"properties": {"author": {"type": "string"}}}`)
+	jsb.AddSchema("sdk-test", schema)
+
+	// the patched document is checked locally instead of being patched by the server
+	_, err := jsb.PatchOwnDocument("sdk-test/index.json", []types.PatchOperation{{Op: "replace", Path: "/author", Value: 1}})
+	if err == nil || err.Code != "schema_validation" {
+		t.Errorf("Expected schema validation error, got %v", err)
+	}
+
+	res, err := jsb.PatchOwnDocument("sdk-test/index.json", []types.PatchOperation{{Op: "replace", Path: "/author", Value: "me"}})
+	if err != nil || !res.Changed {
+		t.Errorf("Unexpected patch result %v %v", res, err)
+	}
+
+	if n := atomic.LoadInt32(&patchRequests); n != 0 {
+		t.Errorf("Expected no patch requests, got %v", n)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// examples from RFC 7396 appendix A
+	tests := [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		var document, patch, expected any
+		_ = json.Unmarshal([]byte(test[0]), &document)
+		_ = json.Unmarshal([]byte(test[1]), &patch)
+		_ = json.Unmarshal([]byte(test[2]), &expected)
+
+		result, err := MergePatch(document, patch)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !valuesEqual(result, expected) {
+			t.Errorf("MergePatch(%v, %v) = %v, expected %v", test[0], test[1], result, test[2])
+		}
+	}
+}
+
+func TestModifyOwnDocument(t *testing.T) {
+	var mutex sync.Mutex
+	var content = `{"counter": 0}`
+	var updatedAt = 0
+	// simulates another writer changing the document right after it was read
+	var concurrentWrites = 2
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch {
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/meta/file/"):
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":          "id",
+				"name":        "counter.json",
+				"path":        "counter.json",
+				"project":     "sdk-test",
+				"contentSize": map[string]any{"number": len(content), "string": fmt.Sprintf("%v B", len(content))},
+				"createdAt":   "2022-01-01T00:00:00.000Z",
+				"updatedAt":   fmt.Sprintf("2022-01-01T00:00:%02d.000Z", updatedAt),
+			})
+		case r.Method == "GET":
+			_, _ = w.Write([]byte(content))
+			if concurrentWrites > 0 {
+				concurrentWrites--
+				content = `{"counter": 10}`
+				updatedAt++
+			}
+		case r.Method == "POST":
+			var body struct {
+				Content string `json:"content"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			content = body.Content
+			updatedAt++
+			_ = json.NewEncoder(w).Encode(map[string]any{"changed": true})
+		}
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+
+	increment := func(content any) (any, error) {
+		data := content.(map[string]any)
+		data["counter"] = data["counter"].(float64) + 1
+		return data, nil
+	}
+
+	// retries are exhausted
+	_, _, err := jsb.ModifyOwnDocument("sdk-test/counter.json", 1, increment)
+	if err == nil || err.Code != ConflictError.Code {
+		t.Errorf("Expected conflict error, got %v", err)
+	}
+
+	concurrentWrites = 1
+	modified, res, err := jsb.ModifyOwnDocument("sdk-test/counter.json", 3, increment)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !res.Changed || modified.(map[string]any)["counter"] != float64(11) || content != `{"counter":11}` {
+		t.Errorf("Unexpected content %v", content)
+	}
+}
+
+func TestCheckVersionIsNotCoalesced(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first read started before the write and answers with the old content
+		if atomic.AddInt32(&hits, 1) == 1 {
+			<-release
+			_, _ = w.Write([]byte(`{"counter": 0}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"counter": 1}`))
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = jsb.GetOwnContentAsString("sdk-test/counter.json")
+	}()
+
+	for atomic.LoadInt32(&hits) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	err := jsb.checkVersion("sdk-test/counter.json", types.DocumentVersion{ContentHash: ContentHash(`{"counter": 1}`)})
+	close(release)
+	<-done
+
+	if err != nil {
+		t.Errorf("Expected the current version, got %v", err)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema, err := CompileSchema(`{
+		"type": "object",
+		"required": ["name", "version"],
+		"properties": {
+			"name": {"type": "string", "minLength": 3, "pattern": "^[a-z-]+$"},
+			"version": {"$ref": "#/$defs/version"},
+			"port": {"type": "integer", "minimum": 1, "exclusiveMaximum": 65536},
+			"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+			"mode": {"enum": ["dev", "prod"]},
+			"replicas": {"type": "number", "multipleOf": 2}
+		},
+		"additionalProperties": false,
+		"$defs": {
+			"version": {"type": "string", "pattern": "^\\d+\\.\\d+\\.\\d+$"}
+		}
+	}`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	violations, err := schema.ValidateJson(`{"name": "api", "version": "1.0.0", "port": 8080, "tags": ["a", "b"], "mode": "dev"}`)
+	if err != nil || len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v %v", violations, err)
+	}
+
+	violations, _ = schema.ValidateJson(`{
+		"name": "A",
+		"port": 80.5,
+		"tags": ["a", "a", 1, "b"],
+		"mode": "test",
+		"replicas": 3,
+		"extra": true
+	}`)
+
+	expected := [][2]string{
+		{"", "required"},
+		{"/name", "minLength"},
+		{"/name", "pattern"},
+		{"/port", "type"},
+		{"/tags", "maxItems"},
+		{"/tags", "uniqueItems"},
+		{"/tags/2", "type"},
+		{"/mode", "enum"},
+		{"/replicas", "multipleOf"},
+		{"/extra", "additionalProperties"},
+	}
+	found := map[[2]string]bool{}
+	for _, violation := range violations {
+		found[[2]string{violation.Path, violation.Keyword}] = true
+	}
+
+	for _, violation := range expected {
+		if !found[violation] {
+			t.Errorf("Expected %v violation at %q, got %v", violation[1], violation[0], violations)
+		}
+	}
+
+	// multipleOf divides decimal numbers exactly
+	for _, test := range []struct {
+		schema, value string
+		valid         bool
+	}{
+		{`{"multipleOf": 0.01}`, `19.99`, true},
+		{`{"multipleOf": 0.1}`, `0.3`, true},
+		{`{"multipleOf": 0.01}`, `0.001`, false},
+		{`{"multipleOf": 0.1}`, `12345678901234567.8`, true},
+	} {
+		multiple, _ := CompileSchema(test.schema)
+		violations, err := multiple.ValidateJson(test.value)
+		if err != nil || (len(violations) == 0) != test.valid {
+			t.Errorf("%v %v: expected valid %v, got %v %v", test.schema, test.value, test.valid, violations, err)
+		}
+	}
+
+	// invalid schemas are rejected when compiled
+	if _, err := CompileSchema(`{"$ref": "#/$defs/missing"}`); err == nil {
+		t.Error("CompileSchema should reject unresolved references")
+	}
+	if _, err := CompileSchema(`{"pattern": "("}`); err == nil {
+		t.Error("CompileSchema should reject invalid patterns")
+	}
+
+	t.Run("AddSchema", func(t *testing.T) {
+		var jsb = Init(Config{Host: "http://localhost:0", Keys: Keys{Public: "public", Private: "private"}})
+		jsb.AddSchema("sdk-test/configs", schema)
+
+		_, err := jsb.CreateDocument(types.CreateDocumentBody{
+			Name:    "api.json",
+			Folder:  "configs",
+			Project: "sdk-test",
+			Content: `{"name": "api"}`,
+		})
+
+		if err == nil || err.Code != "schema_validation" || !strings.Contains(err.Message, `missing required property "version"`) {
+			t.Errorf("Expected schema validation error, got %v", err)
+		}
+
+		// documents outside of the target are not validated
+		_, err = jsb.CreateDocument(types.CreateDocumentBody{
+			Name:    "api.json",
+			Project: "sdk-test",
+			Content: `{"name": "api"}`,
+		})
+
+		if err == nil || err.Code == "schema_validation" {
+			t.Errorf("Expected request error, got %v", err)
+		}
+
+		// instances not created by Init, read while schemas are added
+		var zero Instance
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = zero.hasSchemas()
+		}()
+		zero.AddSchema("sdk-test", schema)
+		wg.Wait()
+
+		if !zero.hasSchemas() {
+			t.Error("Schema was not added")
+		}
+
+		// copies of an instance created by Init share its schemas
+		copied := jsb.WithResponse(&Response{})
+		jsb.AddSchema("other", schema)
+		if len(copied.schemasFor("other/a.json")) != 1 {
+			t.Error("Copy does not share schemas")
+		}
+	})
+}
+
+func TestQueryContent(t *testing.T) {
+	var content any
+	_ = json.Unmarshal([]byte(`{
+		"store": {
+			"book": [
+				{"category": "reference", "author": "Nigel Rees", "price": 8.95},
+				{"category": "fiction", "author": "Evelyn Waugh", "price": 12.99},
+				{"category": "fiction", "author": "Herman Melville", "price": 8.99},
+				{"category": "fiction", "author": "J. R. R. Tolkien", "price": 22.99}
+			],
+			"bicycle": {"color": "red", "price": 19.95},
+			"a/b": {"m~n": 1}
+		}
+	}`), &content)
+
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{"/store/bicycle/color", `"red"`},
+		{"/store/book/1/author", `"Evelyn Waugh"`},
+		{"/store/a~1b/m~0n", `1`},
+		{"$.store.bicycle.color", `["red"]`},
+		{"$.store.book[*].author", `["Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"]`},
+		{"$..author", `["Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"]`},
+		{"$.store..price", `[19.95, 8.95, 12.99, 8.99, 22.99]`},
+		{"$..book[2].author", `["Herman Melville"]`},
+		{"$..book[-1].author", `["J. R. R. Tolkien"]`},
+		{"$..book[0,1].price", `[8.95, 12.99]`},
+		{"$..book[:2].category", `["reference", "fiction"]`},
+		{"$..book[::-2].price", `[22.99, 12.99]`},
+		{"$['store']['bicycle']['price']", `[19.95]`},
+		{"$.store.bicycle.*", `["red", 19.95]`},
+	}
+
+	for _, test := range tests {
+		value, err := QueryContent(content, test.expression)
+		if err != nil {
+			t.Errorf("%v: %v", test.expression, err)
+			continue
+		}
+
+		var expected any
+		_ = json.Unmarshal([]byte(test.expected), &expected)
+		if !valuesEqual(value, expected) {
+			t.Errorf("%v: expected %v, got %v", test.expression, test.expected, value)
+		}
+	}
+
+	for _, expression := range []string{"/store/missing", "/store/book/4", "$.store.missing", "$..book[10]"} {
+		if _, err := QueryContent(content, expression); err == nil || err.Code != "path_not_found" {
+			t.Errorf("%v: expected path_not_found, got %v", expression, err)
+		}
+	}
+
+	if _, err := QueryContent(content, "$.store[0"); err == nil || err.Code != "invalid_path" {
+		t.Errorf("Expected invalid_path, got %v", err)
+	}
+
+	t.Run("ValueAt", func(t *testing.T) {
+		price, err := ValueAt[float64](content, "/store/bicycle/price")
+		if err != nil || price != 19.95 {
+			t.Errorf("Expected 19.95, got %v %v", price, err)
+		}
+
+		authors, err := ValueAt[[]string](content, "$..author")
+		if err != nil || len(authors) != 4 {
+			t.Errorf("Expected 4 authors, got %v %v", authors, err)
+		}
+
+		book, err := ValueAt[struct{ Author string }](content, "/store/book/0")
+		if err != nil || book.Author != "Nigel Rees" {
+			t.Errorf("Expected Nigel Rees, got %v %v", book, err)
+		}
+
+		if _, err := ValueAt[int](content, "/store/bicycle/color"); err == nil || err.Code != "invalid_type" {
+			t.Errorf("Expected invalid_type, got %v", err)
+		}
+	})
+
+	t.Run("GetContentAt", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(testFileContent))
+		}))
+		defer server.Close()
+
+		var jsb = InitWithoutKeys()
+		jsb.SetHost(server.URL)
+
+		author, err := jsb.GetContentAt("jsonbank/sdk-test/index.json", "/author")
+		if err != nil || author != "jsonbank" {
+			t.Errorf("Expected jsonbank, got %v %v", author, err)
+		}
+
+		if _, err := jsb.GetContentAt("jsonbank/sdk-test/index.json", "/missing"); err == nil || err.Code != "path_not_found" {
+			t.Errorf("Expected path_not_found, got %v", err)
+		}
+
+		names, err := jsb.GetContentAt("jsonbank/sdk-test/index.json", "$.name")
+		if err != nil || names.([]any)[0] != "JsonBank SDK Test File" {
+			t.Errorf("Expected name, got %v %v", names, err)
+		}
+	})
+}
+
+func TestDiff(t *testing.T) {
+	var from, to any
+	_ = json.Unmarshal([]byte(`{"name": "api", "port": 80, "tags": ["a", "b", "c"], "db": {"host": "localhost", "pool": 5}}`), &from)
+	_ = json.Unmarshal([]byte(`{"name": "api", "port": 8080, "tags": ["a", "x"], "db": {"host": "localhost"}, "debug": true}`), &to)
+
+	changes, err := Diff(from, to)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := FormatChanges([]types.Change{
+		{Op: "remove", Path: "/db/pool", OldValue: 5},
+		{Op: "replace", Path: "/port", OldValue: 80, NewValue: 8080},
+		{Op: "replace", Path: "/tags/1", OldValue: "b", NewValue: "x"},
+		{Op: "remove", Path: "/tags/2", OldValue: "c"},
+		{Op: "add", Path: "/debug", NewValue: true},
+	})
+	if FormatChanges(changes) != expected {
+		t.Errorf("Expected:\n%v\nGot:\n%v", expected, FormatChanges(changes))
+	}
+
+	// the patch turns from into to
+	patched, err := ApplyPatch(from, ChangesToPatch(changes))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !valuesEqual(patched, to) {
+		t.Errorf("Patched value %v does not match %v", patched, to)
+	}
+
+	if changes, _ := Diff(from, from); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+
+	t.Run("DiffOwnDocuments", func(t *testing.T) {
+		bank := newFakeBank()
+		server := httptest.NewServer(bank)
+		defer server.Close()
+
+		var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+		_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "a.json", Project: "sdk-test", Content: `{"id": 12345678901234567891}`})
+		_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "b.json", Project: "sdk-test", Content: `{"id": 12345678901234567892}`})
+
+		// numbers that float64 cannot tell apart
+		for _, diff := range []func() ([]types.Change, *RequestError){
+			func() ([]types.Change, *RequestError) {
+				return jsb.DiffOwnDocuments("sdk-test/a.json", "sdk-test/b.json")
+			},
+			func() ([]types.Change, *RequestError) {
+				return DiffInstances(&jsb, "sdk-test/a.json", &jsb, "sdk-test/b.json")
+			},
+		} {
+			if changes, err := diff(); err != nil || len(changes) != 1 {
+				t.Errorf("Expected 1 change, got %v %v", changes, err)
+			}
+		}
+	})
+
+	t.Run("UnifiedDiff", func(t *testing.T) {
+		diff, err := UnifiedDiff(map[string]any{"a": 1, "b": 2, "c": 3}, map[string]any{"a": 1, "b": 4, "c": 3}, "remote", "local")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		expected := "--- remote\n+++ local\n@@ -1,5 +1,5 @@\n {\n   \"a\": 1,\n-  \"b\": 2,\n+  \"b\": 4,\n   \"c\": 3\n }\n"
+		if diff != expected {
+			t.Errorf("Expected:\n%v\nGot:\n%v", expected, diff)
+		}
+
+		diff, _ = UnifiedDiff(map[string]any{"a": 1}, map[string]any{"a": 1}, "remote", "local")
+		if diff != "" {
+			t.Errorf("Expected empty diff, got %v", diff)
+		}
+	})
+	t.Run("diffLines", func(t *testing.T) {
+		tests := [][2]string{
+			{"", ""},
+			{"", "abc"},
+			{"abc", ""},
+			{"abcabba", "cbabac"},
+			{"xaxbx", "abc"},
+			{"abcdef", "abcdef"},
+		}
+
+		for _, test := range tests {
+			a, b := strings.Split(test[0], ""), strings.Split(test[1], "")
+			var from, to strings.Builder
+			changes := 0
+			for _, edit := range diffLines(a, b) {
+				if edit.kind != '+' {
+					from.WriteString(edit.text)
+				}
+				if edit.kind != '-' {
+					to.WriteString(edit.text)
+				}
+				if edit.kind != ' ' {
+					changes++
+				}
+			}
+
+			if from.String() != test[0] || to.String() != test[1] {
+				t.Errorf("diffLines(%v, %v) does not rebuild the lines", test[0], test[1])
+			}
+
+			// shortest edit script: lines that are not part of the longest common subsequence
+			if expected := len(a) + len(b) - 2*lcsLength(a, b); changes != expected {
+				t.Errorf("diffLines(%v, %v) made %v changes, expected %v", test[0], test[1], changes, expected)
+			}
+		}
+	})
+}
+
+// lcsLength - length of the longest common subsequence of two lists
+func lcsLength(a []string, b []string) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] > lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	return lengths[0][0]
+}
+
+func TestCanonicalJson(t *testing.T) {
+	tests := [][2]string{
+		{`{"b": 1, "a": [1.0, 1e2, -0, 0.5, 12345678901234567890123, 1E-7, 2.50]}`, `{"a":[1,100,0,0.5,12345678901234567890123,1e-7,2.5],"b":1}`},
+		{`{"html": "<a href=\"x\">&</a>", "unicode": "é"}`, `{"html":"<a href=\"x\">&</a>","unicode":"é"}`},
+		{` [ ] `, `[]`},
+		{`{"z": {}, "y": null, "x": true}`, `{"x":true,"y":null,"z":{}}`},
+		// numbers float64 cannot hold keep every significant digit
+		{`[12345678901234567.89, 3.141592653589793238462643, -0.000000123450, 1.5e21, 1e21, -0.0e5]`,
+			`[12345678901234567.89,3.141592653589793238462643,-1.2345e-7,1.5e+21,1e+21,0]`},
+		{`[0.0001e2, 120e-1, 1234.5e-10, 100e18, 123E+2]`, `[0.01,12,1.2345e-7,100000000000000000000,12300]`},
+	}
+
+	for _, test := range tests {
+		canonical, err := CanonicalJson(test[0], "")
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if canonical != test[1] {
+			t.Errorf("Expected %v, got %v", test[1], canonical)
+		}
+	}
+
+	indented, _ := CanonicalJson(`{"b": [1, 2], "a": {"c": 1}}`, "  ")
+	if indented != "{\n  \"a\": {\n    \"c\": 1\n  },\n  \"b\": [\n    1,\n    2\n  ]\n}" {
+		t.Errorf("Unexpected indentation %v", indented)
+	}
+
+	if _, err := CanonicalJson(`{"a": 1} x`, ""); err == nil {
+		t.Error("CanonicalJson should reject invalid json")
+	}
+
+	if !IsSameJson(`{"a": 1.0, "b": [true]}`, `{"b":[true],"a":1}`) || IsSameJson(`{"a": 1}`, `{"a": 2}`) {
+		t.Error("IsSameJson should compare json semantically")
+	}
+
+	t.Run("SkipUnchanged", func(t *testing.T) {
+		var content = `{"name": "api", "port": 80}`
+		var updates int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				_, _ = w.Write([]byte(content))
+				return
+			}
+
+			var body struct {
+				Content string `json:"content"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			content = body.Content
+			updates++
+			_ = json.NewEncoder(w).Encode(map[string]any{"changed": true})
+		}))
+		defer server.Close()
+
+		var jsb = Init(Config{
+			Host:      server.URL,
+			Keys:      Keys{Public: "public", Private: "private"},
+			Canonical: Canonical{Enabled: true, SkipUnchanged: true},
+		})
+
+		res, err := jsb.UpdateOwnDocument("sdk-test/api.json", `{"port": 80.0, "name": "api"}`)
+		if err != nil || res.Changed || updates != 0 {
+			t.Errorf("Semantically equal content should not be written: %v %v", res, err)
+		}
+
+		res, err = jsb.UpdateOwnDocument("sdk-test/api.json", `{"port": 8080, "name": "api"}`)
+		if err != nil || !res.Changed || content != `{"name":"api","port":8080}` {
+			t.Errorf("Content should be written canonically, got %v %v", content, err)
+		}
+	})
+
+	t.Run("SkipUnchanged after a concurrent write", func(t *testing.T) {
+		var reads int32
+		var updates int32
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				atomic.AddInt32(&updates, 1)
+				_ = json.NewEncoder(w).Encode(map[string]any{"changed": true})
+				return
+			}
+
+			// the first read started before the document was changed to port 8080
+			if atomic.AddInt32(&reads, 1) == 1 {
+				<-release
+				_, _ = w.Write([]byte(`{"port": 80}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"port": 8080}`))
+		}))
+		defer server.Close()
+
+		var jsb = Init(Config{
+			Host:      server.URL,
+			Keys:      Keys{Public: "public", Private: "private"},
+			Canonical: Canonical{Enabled: true, SkipUnchanged: true},
+		})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = jsb.GetOwnContentAsString("sdk-test/api.json")
+		}()
+
+		for atomic.LoadInt32(&reads) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		res, err := jsb.UpdateOwnDocument("sdk-test/api.json", `{"port": 80}`)
+		close(release)
+		<-done
+
+		if err != nil || !res.Changed || atomic.LoadInt32(&updates) != 1 {
+			t.Errorf("Update should not be skipped: %v %v", res, err)
+		}
+	})
+}
+
+func TestUseNumber(t *testing.T) {
+	const content = `{"id": 12345678901234567891, "price": 19.99}`
+	var written string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1/folder/"):
+			_, _ = w.Write([]byte(`{"id": "f", "name": "folder", "path": "folder", "project": "sdk-test",
+				"createdAt": "", "updatedAt": "", "stats": {"documents": 3, "folders": 1}}`))
+		case r.Method == "POST":
+			var body struct {
+				Content string `json:"content"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			written = body.Content
+			_, _ = w.Write([]byte(`{"changed": true}`))
+		default:
+			_, _ = w.Write([]byte(content))
+		}
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}, UseNumber: true})
+
+	data, err := jsb.GetOwnContent("sdk-test/ids.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if id := data.(map[string]any)["id"]; id != json.Number("12345678901234567891") {
+		t.Errorf("Expected exact id, got %v", id)
+	}
+
+	folder, err := jsb.GetFolderWithStats("sdk-test/folder")
+	if err != nil || folder.Stats.Documents != 3 || folder.Stats.Folders != 1 {
+		t.Errorf("Unexpected folder stats %v %v", folder, err)
+	}
+
+	// numbers keep their precision when documents are modified, even without UseNumber
+	jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+	_, _, err = jsb.MergeOwnDocument("sdk-test/ids.json", map[string]any{"price": 24.99})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if written != `{"id":12345678901234567891,"price":24.99}` {
+		t.Errorf("Unexpected content %v", written)
+	}
+}
+
+func TestCheckJson(t *testing.T) {
+	rules := Validation{DisallowDuplicateKeys: true, MaxDepth: 3, MaxSize: 200, MaxStringLength: 10}
+
+	tests := []struct {
+		content string
+		line    int
+		column  int
+	}{
+		{`{"a": 1, "b": [1, {"c": "ok"}]}`, 0, 0},
+		{`{"a": {"a": 1}, "b": {"a": 2}}`, 0, 0},
+		{"{\n  \"a\": 1,\n  \"a\": 2\n}", 3, 3},
+		{"[[[[1]]]]", 1, 4},
+		{`{"name": "a very long name"}`, 1, 10},
+		{"{\n  \"a\": 1,\n}", 3, 1},
+		{`{"a": 1} {"b": 2}`, 1, 10},
+		{`{"a": `, 1, 7},
+	}
+
+	for _, test := range tests {
+		problem := CheckJson([]byte(test.content), rules)
+		if test.line == 0 {
+			if problem != nil {
+				t.Errorf("Unexpected problem in %v: %v", test.content, problem)
+			}
+			continue
+		}
+
+		if problem == nil || problem.Line != test.line || problem.Column != test.column {
+			t.Errorf("Expected problem at %v:%v in %q, got %+v", test.line, test.column, test.content, problem)
+		}
+	}
+
+	if problem := CheckJson([]byte(strings.Repeat(" ", 201)+"1"), rules); problem == nil {
+		t.Error("Expected size problem")
+	}
+
+	// writes are checked before they are sent
+	var sent bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = true
+		_, _ = w.Write([]byte(`{"changed": true}`))
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}, Validation: rules})
+	_, err := jsb.UpdateOwnDocument("sdk-test/doc.json", `{"a": 1, "a": 2}`)
+	if err == nil || err.Code != "json_validation" || sent {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+
+	_, err = jsb.UpdateOwnDocument("sdk-test/doc.json", `{"a": 1, "b": 2}`)
+	if err != nil || !sent {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestConvertToJson(t *testing.T) {
+	converted := [][3]string{
+		{FormatJsonc, "{\n  // port of the server\n  \"port\": 8080, /* default */\n  \"hosts\": [\"a\", \"b\",],\n}", `{"port":8080,"hosts":["a","b"]}`},
+		{FormatJson5, `{name: 'it\'s "here"', hex: 0xFF, neg: -0x10, half: .5, whole: 5., plus: +1, big: 1e+5}`,
+			`{"name":"it's \"here\"","hex":255,"neg":-16,"half":0.5,"whole":5,"plus":1,"big":1e+5}`},
+		{FormatJson5, "['line \\\n continued', '\\x41\\v', $id_1,]", ""},
+		{FormatJson5, "{$id_1: null, 'a': [true, false]}", `{"$id_1":null,"a":[true,false]}`},
+		{FormatJson5, "'line \\\ncontinued \\x41'", `"line continued \u0041"`},
+	}
+
+	for _, test := range converted {
+		result, err := ConvertToJson([]byte(test[1]), test[0])
+		if test[2] == "" {
+			if err == nil {
+				t.Errorf("Expected error for %q", test[1])
+			}
+			continue
+		}
+
+		if err != nil || string(result) != test[2] {
+			t.Errorf("Unexpected conversion of %q: %s %v", test[1], result, err)
+		}
+	}
+
+	failures := [][3]string{
+		{FormatJsonc, "{\n  \"a\": 'single'\n}", "line 2, column 8"},
+		{FormatJsonc, "{\n  a: 1\n}", "line 2, column 3"},
+		{FormatJson5, "{\n  a: 1,\n  b: Infinity\n}", "line 3, column 6"},
+		{FormatJson5, "{\n  a: 1 /* open", "line 2, column 8"},
+		{FormatJson5, "[1, 2] 3", "line 1, column 8"},
+		{FormatJson5, "{a: 01}", "line 1, column 5"},
+	}
+
+	for _, test := range failures {
+		_, err := ConvertToJson([]byte(test[1]), test[0])
+		if err == nil || err.Code != "invalid_"+test[0] || !strings.HasPrefix(err.Message, test[2]) {
+			t.Errorf("Expected error at %v for %q, got %v", test[2], test[1], err)
+		}
+	}
+
+	// uploads detect the format from the file extension
+	var received types.CreateDocumentBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"id": "id", "name": "app.json", "path": "app.json", "project": "sdk-test", "createdAt": ""}`))
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+	_, err := jsb.UploadDocument(types.UploadDocumentBody{
+		FS:       fstest.MapFS{"app.jsonc": {Data: []byte("{\"debug\": true, // local only\n}")}},
+		FilePath: "app.jsonc",
+		Project:  "sdk-test",
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if received.Name != "app.json" || received.Content != `{"debug":true}` {
+		t.Errorf("Unexpected upload %+v", received)
+	}
+}
+
+func TestConvertFormats(t *testing.T) {
+	const content = `{"name":"app","port":8080,"ratio":0.75,"debug":false,"id":-1234567890123456789,` +
+		`"tags":["a","b"],"db":{"host":"localhost","replicas":[{"host":"r1"},{"host":"r2"}]}}`
+
+	for _, format := range []string{FormatYaml, FormatToml} {
+		converted, err := ConvertFromJson([]byte(content), format)
+		if err != nil {
+			t.Errorf("%v: %v", format, err)
+			continue
+		}
+
+		back, err := ConvertToJson(converted, format)
+		if err != nil {
+			t.Errorf("%v: %v\n%s", format, err, converted)
+			continue
+		}
+
+		if !IsSameJson(string(back), content) {
+			t.Errorf("%v round trip changed content: %s", format, back)
+		}
+	}
+
+	// yaml keeps the key order, expands anchors and merge keys
+	yamlContent := "b: 1\na: &base\n  x: 1\n  y: \"2\"\nc:\n  <<: *base\n  y: 3\nd: 2001-12-14\ne: 0x1F\n"
+	converted, err := ConvertToJson([]byte(yamlContent), FormatYaml)
+	if err != nil || string(converted) != `{"b":1,"a":{"x":1,"y":"2"},"c":{"x":1,"y":3},"d":"2001-12-14","e":31}` {
+		t.Errorf("Unexpected yaml conversion %s %v", converted, err)
+	}
+
+	// merged keys are inserted where the merge key appears, earlier sources and the mapping's own keys win
+	yamlContent = "a: &a {x: 1, y: 1}\nb: &b {y: 2, z: 2}\nc:\n  z: 3\n  <<: [*a, *b]\n  w: 3\n  x: 3\n"
+	converted, err = ConvertToJson([]byte(yamlContent), FormatYaml)
+	if err != nil || !strings.HasSuffix(string(converted), `"c":{"z":3,"y":1,"w":3,"x":3}}`) {
+		t.Errorf("Unexpected yaml merge %s %v", converted, err)
+	}
+
+	// aliases that expand to too many values are rejected, in sequences and in merge keys
+	bomb := "a: &a [x, x, x, x, x, x, x, x, x, x]\n"
+	mergeBomb := "a: &a {x: 1}\n"
+	for _, level := range []string{"b", "c", "d", "e", "f", "g", "h", "i"} {
+		previous := string(rune(level[0] - 1))
+		bomb += fmt.Sprintf("%v: &%v [*%v, *%v, *%v, *%v, *%v, *%v, *%v, *%v, *%v, *%v]\n", level, level,
+			previous, previous, previous, previous, previous, previous, previous, previous, previous, previous)
+		mergeBomb += fmt.Sprintf("%v: &%v {<<: [*%v, *%v, *%v, *%v, *%v, *%v, *%v, *%v, *%v, *%v]}\n", level, level,
+			previous, previous, previous, previous, previous, previous, previous, previous, previous, previous)
+	}
+	for _, document := range []string{bomb, mergeBomb} {
+		if _, err = ConvertToJson([]byte(document), FormatYaml); err == nil || err.Code != "yaml_too_large" {
+			t.Errorf("Expected yaml_too_large, got %v", err)
+		}
+	}
+
+	// numbers keep their precision in yaml
+	converted, _ = ConvertFromJson([]byte(`{"id":12345678901234567891,"s":"true"}`), FormatYaml)
+	if back, err := ConvertToJson(converted, FormatYaml); err != nil || string(back) != `{"id":12345678901234567891,"s":"true"}` {
+		t.Errorf("Unexpected yaml round trip %s %v", back, err)
+	}
+
+	// toml errors report their position
+	_, err = ConvertToJson([]byte("a = 1\nb = = 2\n"), FormatToml)
+	if err == nil || err.Code != "invalid_toml" || !strings.HasPrefix(err.Message, "line 2") {
+		t.Errorf("Expected toml error, got %v", err)
+	}
+
+	for _, unsupported := range []string{`{"a": null}`, `{"id": 12345678901234567891}`, `[1]`} {
+		_, err = ConvertFromJson([]byte(unsupported), FormatToml)
+		if err == nil || err.Code != "unsupported_content" {
+			t.Errorf("Expected unsupported content for %v, got %v", unsupported, err)
+		}
+	}
+
+	// csv round trips records exactly
+	const csvContent = "name,age,note\nalice,30,\"likes \"\"json\"\"\"\nbob,25,\n"
+	converted, err = ConvertToJson([]byte(csvContent), FormatCsv)
+	if err != nil || string(converted) != `[{"name":"alice","age":"30","note":"likes \"json\""},{"name":"bob","age":"25","note":""}]` {
+		t.Errorf("Unexpected csv conversion %s %v", converted, err)
+	}
+
+	back, err := ConvertFromJson(converted, FormatCsv)
+	if err != nil || string(back) != csvContent {
+		t.Errorf("Unexpected csv round trip %q %v", back, err)
+	}
+
+	back, err = ConvertFromJson([]byte(`[{"b":1,"a":null},{"a":[1,2],"c":true}]`), FormatCsv)
+	if err != nil || string(back) != "b,a,c\n1,,\n,\"[1,2]\",true\n" {
+		t.Errorf("Unexpected csv export %q %v", back, err)
+	}
+
+	// uploads detect the format and exports render it
+	var received types.CreateDocumentBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			_, _ = w.Write([]byte(content))
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"id": "id", "name": "app.json", "path": "app.json", "project": "sdk-test", "createdAt": ""}`))
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+	_, err = jsb.UploadDocument(types.UploadDocumentBody{
+		FS:       fstest.MapFS{"app.toml": {Data: []byte("port = 8080\n[db]\nhost = \"localhost\"\n")}},
+		FilePath: "app.toml",
+		Project:  "sdk-test",
+	})
+	if err != nil || received.Name != "app.json" || received.Content != `{"db":{"host":"localhost"},"port":8080}` {
+		t.Errorf("Unexpected upload %+v %v", received, err)
+	}
+
+	exported, err := jsb.GetOwnContentAs("sdk-test/app.json", FormatYaml)
+	if err != nil || !strings.Contains(exported, "id: -1234567890123456789\n") || !strings.Contains(exported, "replicas:\n") {
+		t.Errorf("Unexpected export %v %v", exported, err)
+	}
+}
+
+func TestListFolder(t *testing.T) {
+	document := func(name string) map[string]any {
+		return map[string]any{"id": name, "name": name, "project": "sdk-test", "path": "configs/" + name,
+			"contentSize": map[string]any{"number": 2, "string": "2 B"}, "folderId": "f", "createdAt": "", "updatedAt": ""}
+	}
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.String())
+
+		switch r.URL.Path {
+		case "/v1/folder/sdk-test/configs/contents":
+			if r.URL.Query().Get("page") == "1" {
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"documents": []any{document("a.json")},
+					"folders": []any{map[string]any{"id": "f2", "name": "nested", "path": "configs/nested", "project": "sdk-test",
+						"parentFolder": "f", "createdAt": "", "updatedAt": ""}},
+					"hasMore": true,
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"documents": []any{document("b.json")}, "folders": []any{}, "hasMore": false})
+		case "/v1/project/sdk-test/contents":
+			_ = json.NewEncoder(w).Encode(map[string]any{"documents": []any{}, "folders": []any{}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"code": "notFound", "message": "Not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+
+	contents, err := jsb.ListFolder("sdk-test/configs")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(contents.Documents) != 2 || contents.Documents[1].Name != "b.json" || contents.Documents[0].ContentSize.Number != 2 {
+		t.Errorf("Unexpected documents %+v", contents.Documents)
+	}
+
+	if len(contents.Folders) != 1 || contents.Folders[0].Path != "configs/nested" || contents.Folders[0].ParentFolder != "f" {
+		t.Errorf("Unexpected folders %+v", contents.Folders)
+	}
+
+	page, err := jsb.ListFolderPage("sdk-test/configs", types.ListOptions{Page: 2, Limit: 1})
+	if err != nil || page.Page != 2 || page.HasMore || len(page.Documents) != 1 {
+		t.Errorf("Unexpected page %+v %v", page, err)
+	}
+
+	if last := requests[len(requests)-1]; last != "/v1/folder/sdk-test/configs/contents?limit=1&page=2" {
+		t.Errorf("Unexpected request %v", last)
+	}
+
+	root, err := jsb.ListProject("sdk-test")
+	if err != nil || len(root.Documents) != 0 || len(root.Folders) != 0 {
+		t.Errorf("Unexpected project listing %+v %v", root, err)
+	}
+
+	if _, err := jsb.ListFolder("sdk-test/missing"); err == nil || err.Code != "notFound" {
+		t.Errorf("Expected notFound, got %v", err)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	// sdk-test: index.json, configs/{b.json, a.json, nested/c.json}, skipped/d.json
+	folder := func(path string) map[string]any {
+		name := path[strings.LastIndex(path, "/")+1:]
+		return map[string]any{"id": path, "name": name, "path": path, "project": "sdk-test", "createdAt": "", "updatedAt": ""}
+	}
+	document := func(path string) map[string]any {
+		name := path[strings.LastIndex(path, "/")+1:]
+		return map[string]any{"id": path, "name": name, "project": "sdk-test", "path": path,
+			"contentSize": map[string]any{"number": 2, "string": "2 B"}, "createdAt": "", "updatedAt": ""}
+	}
+	listings := map[string]map[string]any{
+		"/v1/project/sdk-test/contents": {"documents": []any{document("index.json")}, "folders": []any{folder("skipped"), folder("configs")}},
+		"/v1/folder/sdk-test/configs/contents": {"documents": []any{document("configs/b.json"), document("configs/a.json")},
+			"folders": []any{folder("configs/nested")}},
+		"/v1/folder/sdk-test/configs/nested/contents": {"documents": []any{document("configs/nested/c.json")}},
+		"/v1/folder/sdk-test/skipped/contents":        {"documents": []any{document("skipped/d.json")}},
+	}
+
+	var active, maxActive int32
+	var mu sync.Mutex
+	requested := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = true
+		mu.Unlock()
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		if listing, ok := listings[r.URL.Path]; ok {
+			_ = json.NewEncoder(w).Encode(listing)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/v1/folder/") {
+			_ = json.NewEncoder(w).Encode(folder(strings.TrimPrefix(r.URL.Path, "/v1/folder/sdk-test/")))
+			return
+		}
+
+		// content of documents is their path
+		_ = json.NewEncoder(w).Encode(map[string]any{"path": strings.TrimPrefix(r.URL.Path, "/v1/file/")})
+	}))
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+
+	var visited []string
+	err := jsb.WalkWithOptions(context.Background(), "sdk-test", WalkOptions{Concurrency: 2, LoadContent: true}, func(entry *types.WalkEntry, err *RequestError) error {
+		if err != nil {
+			return err
+		}
+
+		visited = append(visited, entry.Path)
+		if entry.Path == "sdk-test/skipped" {
+			return SkipDir
+		}
+
+		if !entry.IsFolder() && entry.Content.(map[string]any)["path"] != entry.Path {
+			t.Errorf("Unexpected content of %v: %v", entry.Path, entry.Content)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := "sdk-test sdk-test/configs sdk-test/configs/a.json sdk-test/configs/b.json sdk-test/configs/nested " +
+		"sdk-test/configs/nested/c.json sdk-test/index.json sdk-test/skipped"
+	if strings.Join(visited, " ") != expected {
+		t.Errorf("Unexpected walk %v", visited)
+	}
+
+	if maxActive > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %v", maxActive)
+	}
+
+	// walking a folder, a document returning SkipDir skips the rest of its folder
+	visited = nil
+	err = jsb.Walk(context.Background(), "sdk-test/configs", func(entry *types.WalkEntry, err *RequestError) error {
+		visited = append(visited, entry.Path)
+		if entry.Path == "sdk-test/configs/a.json" {
+			return SkipDir
+		}
+		return nil
+	})
+	if err != nil || strings.Join(visited, " ") != "sdk-test/configs sdk-test/configs/a.json" {
+		t.Errorf("Unexpected walk %v %v", visited, err)
+	}
+
+	// errors returned by fn stop the walk
+	stop := errors.New("stop")
+	err = jsb.Walk(context.Background(), "sdk-test", func(entry *types.WalkEntry, err *RequestError) error {
+		return stop
+	})
+	if err != stop {
+		t.Errorf("Expected stop, got %v", err)
+	}
+
+	// requests started ahead of fn are not sent once the walk stopped
+	mu.Lock()
+	requested = map[string]bool{}
+	mu.Unlock()
+	err = jsb.WalkWithOptions(context.Background(), "sdk-test", WalkOptions{Concurrency: 1, LoadContent: true}, func(entry *types.WalkEntry, err *RequestError) error {
+		if entry.Path == "sdk-test/configs" {
+			return stop
+		}
+		return nil
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err != stop || requested["/v1/folder/sdk-test/skipped/contents"] || requested["/v1/file/sdk-test/index.json"] {
+		t.Errorf("Expected no request for the rest of the walk %v %v", requested, err)
+	}
+}
+
+// fakeBank - in-memory server implementing the document and folder endpoints used by the tests
+type fakeBank struct {
+	mu          sync.Mutex
+	documents   map[string]*fakeEntry // by id
+	folders     map[string]*fakeEntry // by id
+	projects    map[string]*fakeEntry // by name
+	nextId      int
+	nativeMoves bool   // support rename and move endpoints
+	failCreate  string // name of documents that cannot be created
+	failDelete  string // path of folders that cannot be deleted
+	sizeOffset  int    // added to the content size reported in metadata, like a server storing formatted content
+	requests    []string
+}
+
+// fakeEntry - a document or folder of fakeBank
+type fakeEntry struct {
+	id      string
+	project string
+	path    string // path inside the project
+	content string
+	updated int
+}
+
+func newFakeBank() *fakeBank {
+	return &fakeBank{documents: map[string]*fakeEntry{}, folders: map[string]*fakeEntry{}, projects: map[string]*fakeEntry{}}
+}
+
+// find - finds an entry by id or full path
+func (bank *fakeBank) find(entries map[string]*fakeEntry, idOrPath string) *fakeEntry {
+	if entry, ok := entries[idOrPath]; ok {
+		return entry
+	}
+	for _, entry := range entries {
+		if entry.project+"/"+entry.path == idOrPath {
+			return entry
+		}
+	}
+	return nil
+}
+
+// add - adds an entry, the parent folder must exist
+func (bank *fakeBank) add(entries map[string]*fakeEntry, project string, folder string, name string, content string) (*fakeEntry, string) {
+	p := name
+	if folder != "" {
+		if bank.find(bank.folders, project+"/"+folder) == nil {
+			return nil, "folder.notFound"
+		}
+		p = folder + "/" + name
+	}
+
+	if bank.find(bank.documents, project+"/"+p) != nil || bank.find(bank.folders, project+"/"+p) != nil {
+		return nil, "name.exists"
+	}
+
+	bank.nextId++
+	entry := &fakeEntry{id: fmt.Sprintf("id%v", bank.nextId), project: project, path: p, content: content}
+	entries[entry.id] = entry
+	return entry, ""
+}
+
+func (entry *fakeEntry) name() string {
+	return entry.path[strings.LastIndex(entry.path, "/")+1:]
+}
+
+func (entry *fakeEntry) parent() string {
+	if i := strings.LastIndex(entry.path, "/"); i >= 0 {
+		return entry.path[:i]
+	}
+	return ""
+}
+
+// meta - returns the metadata of a document
+func (bank *fakeBank) meta(entry *fakeEntry) map[string]any {
+	size := len(entry.content) + bank.sizeOffset
+	return map[string]any{
+		"id":          entry.id,
+		"name":        entry.name(),
+		"project":     entry.project,
+		"path":        entry.path,
+		"contentSize": map[string]any{"number": size, "string": fmt.Sprintf("%v B", size)},
+		"createdAt":   "2022-01-01T00:00:00.000Z",
+		"updatedAt":   fmt.Sprintf("2022-01-01T00:00:%02d.000Z", entry.updated),
+	}
+}
+
+func (entry *fakeEntry) folder() map[string]any {
+	return map[string]any{"id": entry.id, "name": entry.name(), "project": entry.project, "path": entry.path,
+		"createdAt": "2022-01-01T00:00:00.000Z", "updatedAt": "2022-01-01T00:00:00.000Z"}
+}
+
+// project - returns a project with its stats
+func (bank *fakeBank) project(project *fakeEntry) map[string]any {
+	stats := map[string]any{"documents": 0, "folders": 0}
+	for kind, entries := range map[string]map[string]*fakeEntry{"documents": bank.documents, "folders": bank.folders} {
+		for _, entry := range entries {
+			if entry.project == project.path {
+				stats[kind] = stats[kind].(int) + 1
+			}
+		}
+	}
+
+	return map[string]any{"id": project.id, "name": project.path, "description": "", "public": false,
+		"createdAt": "2022-01-01T00:00:00.000Z", "updatedAt": "2022-01-01T00:00:00.000Z", "stats": stats}
+}
+
+// contents - lists the entries directly inside a folder path of a project
+func (bank *fakeBank) contents(project string, folder string) map[string]any {
+	documents, folders := []any{}, []any{}
+	for _, id := range bank.sortedIds(bank.documents) {
+		if entry := bank.documents[id]; entry.project == project && entry.parent() == folder {
+			documents = append(documents, bank.meta(entry))
+		}
+	}
+	for _, id := range bank.sortedIds(bank.folders) {
+		if entry := bank.folders[id]; entry.project == project && entry.parent() == folder {
+			folders = append(folders, entry.folder())
+		}
+	}
+	return map[string]any{"documents": documents, "folders": folders}
+}
+
+func (bank *fakeBank) sortedIds(entries map[string]*fakeEntry) []string {
+	var ids []string
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// move - moves an entry and everything inside it to a new path
+func (bank *fakeBank) move(entry *fakeEntry, newPath string) {
+	old := entry.path
+	for _, entries := range []map[string]*fakeEntry{bank.documents, bank.folders} {
+		for _, e := range entries {
+			if e.project == entry.project && strings.HasPrefix(e.path, old+"/") {
+				e.path = newPath + e.path[len(old):]
+			}
+		}
+	}
+	entry.path = newPath
+}
+
+func (bank *fakeBank) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.requests = append(bank.requests, r.Method+" "+r.URL.Path)
+
+	reply := func(status int, data any) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(data)
+	}
+	fail := func(status int, code string) {
+		reply(status, map[string]any{"error": map[string]any{"code": code, "message": code}})
+	}
+
+	var body map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	route := strings.TrimPrefix(r.URL.Path, "/v1")
+	switch {
+	case route == "/projects" && r.Method == "POST":
+		if bank.projects[body["name"]] != nil {
+			fail(http.StatusConflict, "name.exists")
+			return
+		}
+		bank.nextId++
+		project := &fakeEntry{id: fmt.Sprintf("id%v", bank.nextId), path: body["name"]}
+		bank.projects[project.path] = project
+		reply(http.StatusOK, bank.project(project))
+	case route == "/projects":
+		projects := []any{}
+		for _, id := range bank.sortedIds(bank.projects) {
+			projects = append(projects, bank.project(bank.projects[id]))
+		}
+		reply(http.StatusOK, map[string]any{"projects": projects})
+	case strings.HasPrefix(route, "/project/") && strings.Count(route, "/") == 2:
+		project := bank.projects[strings.TrimPrefix(route, "/project/")]
+		if project == nil {
+			fail(http.StatusNotFound, "notFound")
+			return
+		}
+
+		if r.Method == "DELETE" {
+			delete(bank.projects, project.path)
+			for _, entries := range []map[string]*fakeEntry{bank.documents, bank.folders} {
+				for id, entry := range entries {
+					if entry.project == project.path {
+						delete(entries, id)
+					}
+				}
+			}
+			reply(http.StatusOK, map[string]any{"deleted": true})
+			return
+		}
+		reply(http.StatusOK, bank.project(project))
+	case strings.HasPrefix(route, "/meta/file/"):
+		if entry := bank.find(bank.documents, strings.TrimPrefix(route, "/meta/file/")); entry != nil {
+			reply(http.StatusOK, bank.meta(entry))
+			return
+		}
+		fail(http.StatusNotFound, "notFound")
+	case strings.HasSuffix(route, "/rename") || strings.HasSuffix(route, "/move"):
+		if !bank.nativeMoves {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		parts := strings.Split(strings.TrimPrefix(route, "/"), "/")
+		entries := bank.documents
+		if parts[0] == "folder" {
+			entries = bank.folders
+		}
+		entry := entries[parts[1]]
+		if entry == nil {
+			fail(http.StatusNotFound, "notFound")
+			return
+		}
+
+		newPath := entry.parent() + "/" + body["name"]
+		if parts[2] == "move" {
+			newPath = body["folder"] + "/" + entry.name()
+		}
+		bank.move(entry, strings.TrimPrefix(newPath, "/"))
+
+		if parts[0] == "folder" {
+			reply(http.StatusOK, entry.folder())
+		} else {
+			reply(http.StatusOK, bank.meta(entry))
+		}
+	case strings.HasPrefix(route, "/project/") && strings.HasSuffix(route, "/contents"):
+		reply(http.StatusOK, bank.contents(strings.Split(route, "/")[2], ""))
+	case strings.HasPrefix(route, "/project/") && r.Method == "POST":
+		parts := strings.Split(route, "/")
+		entries := bank.documents
+		if parts[3] == "folder" {
+			entries = bank.folders
+		}
+
+		if parts[3] == "document" && body["name"] == bank.failCreate {
+			fail(http.StatusInternalServerError, "server.error")
+			return
+		}
+
+		entry, code := bank.add(entries, parts[2], body["folder"], body["name"], body["content"])
+		if code != "" {
+			fail(http.StatusConflict, code)
+			return
+		}
+
+		if parts[3] == "folder" {
+			reply(http.StatusOK, entry.folder())
+		} else {
+			reply(http.StatusOK, map[string]any{"id": entry.id, "name": entry.name(), "path": entry.path,
+				"project": entry.project, "createdAt": "2022-01-01T00:00:00.000Z"})
+		}
+	case strings.HasPrefix(route, "/folder/") && strings.HasSuffix(route, "/contents"):
+		entry := bank.find(bank.folders, strings.TrimSuffix(strings.TrimPrefix(route, "/folder/"), "/contents"))
+		if entry == nil {
+			fail(http.StatusNotFound, "notFound")
+			return
+		}
+		reply(http.StatusOK, bank.contents(entry.project, entry.path))
+	case strings.HasPrefix(route, "/folder/"):
+		entry := bank.find(bank.folders, strings.TrimPrefix(route, "/folder/"))
+		if entry == nil {
+			fail(http.StatusNotFound, "notFound")
+			return
+		}
+
+		if r.Method == "DELETE" {
+			if entry.path == bank.failDelete {
+				fail(http.StatusInternalServerError, "server_error")
+				return
+			}
+			if contents := bank.contents(entry.project, entry.path); len(contents["documents"].([]any))+len(contents["folders"].([]any)) > 0 {
+				fail(http.StatusBadRequest, "folder.notEmpty")
+				return
+			}
+			delete(bank.folders, entry.id)
+			reply(http.StatusOK, map[string]any{"deleted": true})
+			return
+		}
+		reply(http.StatusOK, entry.folder())
+	case strings.HasPrefix(route, "/file/"):
+		entry := bank.find(bank.documents, strings.TrimPrefix(route, "/file/"))
+		if entry == nil {
+			fail(http.StatusNotFound, "notFound")
+			return
+		}
+
+		switch r.Method {
+		case "DELETE":
+			delete(bank.documents, entry.id)
+			reply(http.StatusOK, map[string]any{"deleted": true})
+		case "POST":
+			changed := entry.content != body["content"]
+			if changed {
+				entry.content = body["content"]
+				entry.updated++
+			}
+			reply(http.StatusOK, map[string]any{"changed": changed})
+		default:
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(entry.content))
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestMoveAndDelete(t *testing.T) {
+	for _, native := range []bool{false, true} {
+		bank := newFakeBank()
+		bank.nativeMoves = native
+		server := httptest.NewServer(bank)
+
+		var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+		_, _ = jsb.CreateFolder(types.CreateFolderBody{Name: "configs", Project: "sdk-test"})
+		_, _ = jsb.CreateFolder(types.CreateFolderBody{Name: "nested", Project: "sdk-test", Folder: "configs"})
+		_, _ = jsb.CreateFolder(types.CreateFolderBody{Name: "archive", Project: "sdk-test"})
+		_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "a.json", Project: "sdk-test", Folder: "configs", Content: `{"a":1}`})
+		_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "b.json", Project: "sdk-test", Folder: "configs/nested", Content: `{"b":2}`})
+
+		document, err := jsb.RenameDocument("sdk-test/configs/a.json", "app.json")
+		if err != nil || document.Path != "configs/app.json" || document.OldPath != "configs/a.json" || document.Copied == native {
+			t.Errorf("native %v: unexpected rename %+v %v", native, document, err)
+		}
+
+		document, err = jsb.MoveDocument("sdk-test/configs/app.json", "")
+		if err != nil || document.Path != "app.json" {
+			t.Errorf("native %v: unexpected move %+v %v", native, document, err)
+		}
+
+		folder, err := jsb.RenameFolder("sdk-test/configs", "settings")
+		if err != nil || folder.Path != "settings" || folder.Copied == native {
+			t.Errorf("native %v: unexpected folder rename %+v %v", native, folder, err)
+		}
+
+		folder, err = jsb.MoveFolder("sdk-test/settings", "archive")
+		if err != nil || folder.Path != "archive/settings" {
+			t.Errorf("native %v: unexpected folder move %+v %v", native, folder, err)
+		}
+
+		if content, err := jsb.GetOwnContentAsString("sdk-test/archive/settings/nested/b.json"); err != nil || content != `{"b":2}` {
+			t.Errorf("native %v: document was not moved with its folder %v %v", native, content, err)
+		}
+
+		if _, err := jsb.MoveFolder("sdk-test/archive", "archive/settings"); err == nil || err.Code != "bad_request" {
+			t.Errorf("native %v: expected error moving a folder into itself, got %v", native, err)
+		}
+
+		if _, err := jsb.DeleteFolder("sdk-test/archive", false); err == nil {
+			t.Errorf("native %v: expected error deleting a folder that is not empty", native)
+		}
+
+		deleted, err := jsb.DeleteFolder("sdk-test/archive", true)
+		if err != nil || !deleted.Deleted || deleted.Documents != 1 || deleted.Folders != 2 {
+			t.Errorf("native %v: unexpected delete %+v %v", native, deleted, err)
+		}
+
+		if len(bank.folders) != 0 || len(bank.documents) != 1 {
+			t.Errorf("native %v: unexpected entries left %v %v", native, bank.folders, bank.documents)
+		}
+
+		server.Close()
+	}
+
+	bank := newFakeBank()
+	server := httptest.NewServer(bank)
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+	_, _ = jsb.CreateFolder(types.CreateFolderBody{Name: "partial", Project: "sdk-test"})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "x.json", Project: "sdk-test", Folder: "partial", Content: `{"b": 2,  "a": 1}`})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "y.json", Project: "sdk-test", Folder: "partial", Content: `{}`})
+
+	// copies keep the content as is, whatever the write rules of the instance
+	schema, _ := CompileSchema(`{"required": ["version"]}`)
+	strict := Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}, Canonical: Canonical{Enabled: true}})
+	strict.AddSchema("sdk-test", schema)
+
+	if _, err := strict.RenameDocument("sdk-test/partial/x.json", "z.json"); err != nil {
+		t.Errorf("Unexpected rename error %v", err)
+	}
+
+	if content, _ := jsb.GetOwnContentAsString("sdk-test/partial/z.json"); content != `{"b": 2,  "a": 1}` {
+		t.Errorf("Copied content was changed %v", content)
+	}
+
+	// a failed folder copy is rolled back and the original is kept
+	bank.failCreate = "y.json"
+	if _, err := jsb.RenameFolder("sdk-test/partial", "moved"); err == nil {
+		t.Error("Expected rename error")
+	}
+
+	if _, err := jsb.GetFolder("sdk-test/moved"); err == nil || err.Code != "notFound" {
+		t.Errorf("Expected the partial copy to be deleted %v", err)
+	}
+
+	if len(bank.documents) != 2 || !jsb.HasOwnDocument("sdk-test/partial/y.json") {
+		t.Errorf("Expected the original folder to be kept %v", bank.documents)
+	}
+
+	// the copy is kept when the original cannot be deleted, the error names both
+	bank.failCreate, bank.failDelete = "", "partial"
+	_, err := jsb.RenameFolder("sdk-test/partial", "moved")
+	if err == nil || !strings.Contains(err.Message, "copied to sdk-test/moved") || !strings.Contains(err.Message, "original sdk-test/partial") {
+		t.Errorf("Expected an error naming both folders, got %v", err)
+	}
+
+	if !jsb.HasOwnDocument("sdk-test/moved/y.json") {
+		t.Error("Expected the copy to be kept")
+	}
+}
+
+func TestEnsureFolderPath(t *testing.T) {
+	bank := newFakeBank()
+	server := httptest.NewServer(bank)
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+
+	// concurrent callers create every folder once
+	var wg sync.WaitGroup
+	errs := make([]*RequestError, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = jsb.EnsureFolderPath("sdk-test/a/b/c")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if len(bank.folders) != 3 {
+		t.Errorf("Expected 3 folders, got %v", len(bank.folders))
+	}
+
+	folder, err := jsb.EnsureFolderPath("sdk-test/a/b/c")
+	if err != nil || folder.Path != "a/b/c" {
+		t.Errorf("Unexpected folder %+v %v", folder, err)
+	}
+
+	if _, err := jsb.EnsureFolderPath("sdk-test"); err == nil || err.Code != "bad_request" {
+		t.Errorf("Expected bad_request, got %v", err)
+	}
+
+	// documents can create their folders
+	document := types.CreateDocumentBody{Name: "app.json", Project: "sdk-test", Folder: "x/y", Content: `{}`}
+	if _, err := jsb.CreateDocument(document); err == nil {
+		t.Error("Expected error creating a document in a missing folder")
+	}
+
+	document.CreateFolders = true
+	created, err := jsb.CreateDocument(document)
+	if err != nil || created.Path != "x/y/app.json" {
+		t.Errorf("Unexpected document %+v %v", created, err)
+	}
+
+	// folders are not created for content that is rejected
+	invalid := types.CreateDocumentBody{Name: "app.json", Project: "sdk-test", Folder: "invalid", Content: `{`, CreateFolders: true}
+	if _, err := jsb.CreateDocument(invalid); err == nil {
+		t.Error("Expected invalid content error")
+	}
+
+	if _, err := jsb.GetFolder("sdk-test/invalid"); err == nil || err.Code != "notFound" {
+		t.Errorf("Expected no folder for invalid content %v", err)
+	}
+}
+
+func TestProjects(t *testing.T) {
+	bank := newFakeBank()
+	server := httptest.NewServer(bank)
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+
+	created, err := jsb.CreateProject(types.CreateProjectBody{Name: "sdk-test"})
+	if err != nil || created.Name != "sdk-test" || created.Exists {
+		t.Errorf("Unexpected project %+v %v", created, err)
+	}
+
+	created, err = jsb.CreateProjectIfNotExists(types.CreateProjectBody{Name: "sdk-test"})
+	if err != nil || !created.Exists {
+		t.Errorf("Expected existing project %+v %v", created, err)
+	}
+
+	_, _ = jsb.CreateProject(types.CreateProjectBody{Name: "other"})
+	_, _ = jsb.EnsureFolderPath("sdk-test/configs")
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "a.json", Project: "sdk-test", Folder: "configs", Content: `{}`})
+
+	projects, err := jsb.ListProjects()
+	if err != nil || len(projects) != 2 {
+		t.Errorf("Unexpected projects %+v %v", projects, err)
+	}
+
+	project, err := jsb.GetProjectWithStats("sdk-test")
+	if err != nil || project.Stats == nil || project.Stats.Documents != 1 || project.Stats.Folders != 1 {
+		t.Errorf("Unexpected project %+v %v", project, err)
+	}
+
+	project, err = jsb.GetProject("sdk-test")
+	if err != nil || project.Stats != nil {
+		t.Errorf("Unexpected project %+v %v", project, err)
+	}
+
+	deleted, err := jsb.DeleteProject("sdk-test")
+	if err != nil || !deleted.Deleted || len(bank.documents) != 0 {
+		t.Errorf("Unexpected delete %+v %v", deleted, err)
+	}
+
+	if _, err := jsb.GetProject("sdk-test"); err == nil || err.Code != "notFound" {
+		t.Errorf("Expected notFound, got %v", err)
+	}
+}
+
+func TestProjectFS(t *testing.T) {
+	bank := newFakeBank()
+	server := httptest.NewServer(bank)
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "index.json", Project: "sdk-test", Content: testFileContent})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "app.json", Project: "sdk-test", Folder: "configs/prod", Content: `{"port":80}`, CreateFolders: true})
+	_, _ = jsb.EnsureFolderPath("sdk-test/empty")
+
+	projectFS := jsb.ProjectFS("sdk-test")
+	if err := fstest.TestFS(projectFS, "index.json", "configs/prod/app.json", "empty"); err != nil {
+		t.Error(err)
+	}
+
+	content, err := fs.ReadFile(projectFS, "configs/prod/app.json")
+	if err != nil || string(content) != `{"port":80}` {
+		t.Errorf("Unexpected content %s %v", content, err)
+	}
+
+	info, err := fs.Stat(projectFS, "configs/prod/app.json")
+	if err != nil || info.Size() != 11 || info.ModTime().IsZero() || info.Sys().(*types.DocumentMeta).Path != "configs/prod/app.json" {
+		t.Errorf("Unexpected info %+v %v", info, err)
+	}
+
+	if _, err := fs.Stat(projectFS, "missing.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+
+	matches, err := fs.Glob(projectFS, "configs/*/*.json")
+	if err != nil || len(matches) != 1 || matches[0] != "configs/prod/app.json" {
+		t.Errorf("Unexpected matches %v %v", matches, err)
+	}
+
+	// sizes stored by the server differ from the content read, open files and Stat still agree
+	bank.sizeOffset = 5
+	if err := fstest.TestFS(projectFS, "index.json", "configs/prod/app.json", "empty"); err != nil {
+		t.Error(err)
+	}
+
+	file, err := projectFS.Open("configs/prod/app.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer file.Close()
+
+	info, _ = file.Stat()
+	content, err = io.ReadAll(file)
+	if err != nil || string(content) != `{"port":80}` || info.Size() != 16 {
+		t.Errorf("Unexpected open file %s %v %v", content, info.Size(), err)
+	}
+
+	if length, err := file.(io.Seeker).Seek(0, io.SeekEnd); err != nil || length != 11 {
+		t.Errorf("Unexpected length %v %v", length, err)
+	}
+}
+
+func TestSyncDirectory(t *testing.T) {
+	bank := newFakeBank()
+	server := httptest.NewServer(bank)
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "app.json", Project: "sdk-test", Folder: "deploy", Content: `{"old":true}`, CreateFolders: true})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "same.json", Project: "sdk-test", Folder: "deploy", Content: `{"same":true}`})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "extra.json", Project: "sdk-test", Folder: "deploy", Content: `{}`})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "x.json", Project: "sdk-test", Folder: "deploy/stale/deep", Content: `{}`, CreateFolders: true})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "keep.json", Project: "sdk-test", Folder: "deploy/local", Content: `{}`, CreateFolders: true})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "gone.json", Project: "sdk-test", Folder: "deploy/old", Content: `{}`, CreateFolders: true})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "notes.local.json", Project: "sdk-test", Folder: "deploy/old", Content: `{}`})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.json":          `{"new":true}`,
+		"same.json":         `{"same":true}`,
+		"services/db.yaml":  "host: localhost\nport: 5432\n",
+		"services/api.json": `{"port":80}`,
+		"notes.txt":         "not synced",
+		"local/secret.json": `{}`,
+		"broken.json":       `{`,
+	}
+	for name, content := range files {
+		_ = os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755)
+		_ = os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+
+	options := SyncOptions{Exclude: []string{"local", "*.local.json"}, Delete: true, DryRun: true, Concurrency: 2}
+
+	dryRun, err := jsb.SyncDirectory(dir, "sdk-test", "deploy", options)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	documents := len(bank.documents)
+	if documents != 7 {
+		t.Errorf("Dry run changed documents: %v", documents)
+	}
+
+	options.DryRun = false
+	report, err := jsb.SyncDirectory(dir, "sdk-test", "deploy", options)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, r := range []*SyncReport{dryRun, report} {
+		check := func(name string, got []string, expected ...string) {
+			if strings.Join(got, " ") != strings.Join(expected, " ") {
+				t.Errorf("dry run %v: unexpected %v %v", r.DryRun, name, got)
+			}
+		}
+
+		check("created folders", r.CreatedFolders, "sdk-test/deploy/services")
+		check("created", r.Created, "sdk-test/deploy/services/api.json", "sdk-test/deploy/services/db.json")
+		check("updated", r.Updated, "sdk-test/deploy/app.json")
+		check("unchanged", r.Unchanged, "sdk-test/deploy/same.json")
+		check("deleted", r.Deleted, "sdk-test/deploy/extra.json", "sdk-test/deploy/old/gone.json", "sdk-test/deploy/stale/deep/x.json")
+		check("deleted folders", r.DeletedFolders, "sdk-test/deploy/stale", "sdk-test/deploy/stale/deep")
+
+		if len(r.Errors) != 1 || r.Errors[0].Path != "sdk-test/deploy/broken.json" {
+			t.Errorf("dry run %v: unexpected errors %v", r.DryRun, r.Errors)
+		}
+	}
+
+	if content, _ := jsb.GetOwnContentAsString("sdk-test/deploy/services/db.json"); content != `{"host":"localhost","port":5432}` {
+		t.Errorf("Unexpected content %v", content)
+	}
+
+	if !jsb.HasOwnDocument("sdk-test/deploy/local/keep.json") || jsb.HasOwnDocument("sdk-test/deploy/stale/deep/x.json") {
+		t.Error("Unexpected remote documents after sync")
+	}
+
+	// excluded documents are kept with their folder
+	if !jsb.HasOwnDocument("sdk-test/deploy/old/notes.local.json") || jsb.HasOwnDocument("sdk-test/deploy/old/gone.json") {
+		t.Error("Excluded remote document was deleted")
+	}
+
+	// a second sync has nothing to do
+	report, err = jsb.SyncDirectory(dir, "sdk-test", "deploy", SyncOptions{Exclude: []string{"local", "*.local.json", "broken.json"}, Delete: true})
+	if err != nil || len(report.Created)+len(report.Updated)+len(report.Deleted)+len(report.DeletedFolders) != 0 || len(report.Unchanged) != 4 {
+		t.Errorf("Unexpected report %+v %v", report, err)
+	}
+
+	// include patterns limit deletes to the remote documents they match
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "cache.json", Project: "sdk-test", Folder: "deploy/services", Content: `{}`})
+	report, err = jsb.SyncDirectory(dir, "sdk-test", "deploy", SyncOptions{Include: []string{"*.yaml", "services/*.json"}, Delete: true})
+	if err != nil || strings.Join(report.Deleted, " ") != "sdk-test/deploy/services/cache.json" || len(report.DeletedFolders) != 0 ||
+		strings.Join(report.Unchanged, " ") != "sdk-test/deploy/services/api.json sdk-test/deploy/services/db.json" {
+		t.Errorf("Unexpected include report %+v %v", report, err)
+	}
+
+	if !jsb.HasOwnDocument("sdk-test/deploy/app.json") || !jsb.HasOwnDocument("sdk-test/deploy/services/api.json") {
+		t.Error("Documents the include patterns do not match were deleted")
+	}
+
+	report, err = jsb.SyncDirectory(dir, "sdk-test", "deploy", SyncOptions{Include: []string{"*.yaml"}, Delete: true})
+	if err != nil || len(report.Deleted)+len(report.DeletedFolders) != 0 {
+		t.Errorf("Unexpected include report %+v %v", report, err)
+	}
+}
+
+func TestExportProject(t *testing.T) {
+	bank := newFakeBank()
+	server := httptest.NewServer(bank)
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "index.json", Project: "sdk-test", Content: testFileContent})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "app.json", Project: "sdk-test", Folder: "configs/prod", Content: `{"port":80}`, CreateFolders: true})
+	_, _ = jsb.EnsureFolderPath("sdk-test/empty")
+
+	dir := t.TempDir()
+	report, err := jsb.ExportProject("sdk-test", dir, ExportOptions{Manifest: true, Concurrency: 2})
+	if err != nil || strings.Join(report.Written, " ") != "configs/prod/app.json index.json" {
+		t.Errorf("Unexpected report %+v %v", report, err)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(dir, "configs", "prod", "app.json")); string(content) != `{"port":80}` {
+		t.Errorf("Unexpected content %s", content)
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, "empty")); err != nil || !info.IsDir() {
+		t.Errorf("Expected empty folder %v", err)
+	}
+
+	var manifest types.Manifest
+	data, _ := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err := json.Unmarshal(data, &manifest); err != nil || len(manifest.Documents) != 2 || len(manifest.Folders) != 3 {
+		t.Errorf("Unexpected manifest %s %v", data, err)
+	}
+
+	for _, document := range manifest.Documents {
+		if document.Id == "" || document.UpdatedAt == "" || document.ContentHash == "" {
+			t.Errorf("Incomplete manifest document %+v", document)
+		}
+	}
+
+	// incremental exports only download updated documents
+	_, _ = jsb.UpdateOwnDocument("sdk-test/index.json", `{"updated":true}`)
+	report, err = jsb.ExportProject("sdk-test", dir, ExportOptions{Manifest: true, Incremental: true})
+	if err != nil || strings.Join(report.Written, " ") != "index.json" || strings.Join(report.Skipped, " ") != "configs/prod/app.json" {
+		t.Errorf("Unexpected report %+v %v", report, err)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(dir, "index.json")); string(content) != `{"updated":true}` {
+		t.Errorf("Unexpected content %s", content)
+	}
+
+	// the manifest is not synced back
+	report2, err := jsb.SyncDirectory(dir, "sdk-test", "", SyncOptions{DryRun: true})
+	if err != nil || len(report2.Created) != 0 {
+		t.Errorf("Unexpected sync report %+v %v", report2, err)
+	}
+
+	if _, err := localPath(dir, "../outside.json"); err == nil {
+		t.Error("Expected invalid path")
+	}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	bank := newFakeBank()
+	server := httptest.NewServer(bank)
+	defer server.Close()
+
+	var jsb = Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "index.json", Project: "sdk-test", Content: testFileContent})
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "app.json", Project: "sdk-test", Folder: "configs/prod", Content: `{"port":80}`, CreateFolders: true})
+	_, _ = jsb.EnsureFolderPath("sdk-test/empty")
+	_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: "raw.json", Project: "sdk-test", Folder: "empty", Content: `{"b": 2,  "a": 1}`})
+
+	// restored content is written as is, whatever the write rules of the instance
+	schema, _ := CompileSchema(`{"required": ["version"]}`)
+	strict := Init(Config{Host: server.URL, Keys: Keys{Public: "public", Private: "private"}, Canonical: Canonical{Enabled: true}})
+	strict.AddSchema("restored-tar-gz", schema)
+	strict.AddSchema("restored-zip", schema)
+
+	for _, format := range []string{ArchiveTarGz, ArchiveZip} {
+		var archive bytes.Buffer
+		manifest, err := jsb.BackupWithOptions("sdk-test", &archive, BackupOptions{Format: format, Concurrency: 2})
+		if err != nil || len(manifest.Documents) != 3 || len(manifest.Folders) != 3 {
+			t.Fatalf("Unexpected manifest %+v %v", manifest, err)
+		}
+
+		target := "restored-" + strings.ReplaceAll(format, ".", "-")
+		report, err := strict.Restore(bytes.NewReader(archive.Bytes()), target, RestoreOptions{})
+		if err != nil || strings.Join(report.Created, " ") != "configs/prod/app.json empty/raw.json index.json" || len(report.CreatedFolders) != 3 {
+			t.Errorf("Unexpected report %+v %v", report, err)
+		}
+
+		if content, _ := jsb.GetOwnContentAsString(target + "/configs/prod/app.json"); content != `{"port":80}` {
+			t.Errorf("Unexpected content %s", content)
+		}
+
+		if _, err := jsb.GetFolder(target + "/empty"); err != nil {
+			t.Errorf("Expected empty folder %v", err)
+		}
+
+		// existing documents
+		report, err = jsb.Restore(bytes.NewReader(archive.Bytes()), target, RestoreOptions{})
+		if err != nil || len(report.Skipped) != 3 || len(report.Created) != 0 {
+			t.Errorf("Unexpected report %+v %v", report, err)
+		}
+
+		_, err = jsb.Restore(bytes.NewReader(archive.Bytes()), target, RestoreOptions{Conflict: ConflictFail})
+		if err == nil || err.Code != "conflict" {
+			t.Errorf("Expected conflict %v", err)
+		}
+
+		_, _ = jsb.UpdateOwnDocument(target+"/index.json", `{"changed":true}`)
+		report, err = strict.Restore(bytes.NewReader(archive.Bytes()), target, RestoreOptions{Conflict: ConflictOverwrite})
+		if err != nil || len(report.Overwritten) != 3 {
+			t.Errorf("Unexpected report %+v %v", report, err)
+		}
+
+		if content, _ := jsb.GetOwnContentAsString(target + "/index.json"); content != testFileContent {
+			t.Errorf("Unexpected content %s", content)
+		}
+
+		if content, _ := jsb.GetOwnContentAsString(target + "/empty/raw.json"); content != `{"b": 2,  "a": 1}` {
+			t.Errorf("Restored content was changed %s", content)
+		}
+	}
+
+	// a document that does not match its checksum
+	var archive bytes.Buffer
+	_, _ = jsb.Backup("sdk-test", &archive)
+	gz, _ := gzip.NewReader(&archive)
+	files, _ := io.ReadAll(gz)
+	files = bytes.Replace(files, []byte(`{"port":80}`), []byte(`{"port":81}`), 1)
+
+	var corrupted bytes.Buffer
+	writer := gzip.NewWriter(&corrupted)
+	_, _ = writer.Write(files)
+	_ = writer.Close()
+
+	_, err := jsb.Restore(&corrupted, "corrupted", RestoreOptions{})
+	if err == nil || err.Code != "checksum_mismatch" {
+		t.Errorf("Expected checksum mismatch %v", err)
+	}
+
+	if _, err := jsb.Restore(strings.NewReader("not an archive"), "corrupted", RestoreOptions{}); err == nil || err.Code != "invalid_backup" {
+		t.Errorf("Expected invalid backup %v", err)
+	}
+
+	// archives are read with size limits, e.g. a file that expands to more than its limit
+	var bomb bytes.Buffer
+	gzipWriter := gzip.NewWriter(&bomb)
+	tarWriter := tar.NewWriter(gzipWriter)
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "documents/big.json", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1 << 20})
+	_, _ = tarWriter.Write(make([]byte, 1<<20))
+	_ = tarWriter.Close()
+	_ = gzipWriter.Close()
+
+	_, err = jsb.Restore(&bomb, "limited", RestoreOptions{MaxFileSize: 1 << 10})
+	if err == nil || err.Code != "invalid_backup" || !strings.Contains(err.Message, "documents/big.json") {
+		t.Errorf("Expected invalid backup %v", err)
+	}
+
+	for _, format := range []string{ArchiveTarGz, ArchiveZip} {
+		archive.Reset()
+		_, _ = jsb.BackupWithOptions("sdk-test", &archive, BackupOptions{Format: format})
+		for _, options := range []RestoreOptions{{MaxFileSize: 32}, {MaxTotalSize: 256}} {
+			_, err = jsb.Restore(bytes.NewReader(archive.Bytes()), "limited", options)
+			if err == nil || err.Code != "invalid_backup" {
+				t.Errorf("%v %+v: expected invalid backup %v", format, options, err)
+			}
+		}
+	}
+
+	// no more documents are downloaded once one failed
+	for i := 0; i < 10; i++ {
+		_, _ = jsb.CreateDocument(types.CreateDocumentBody{Name: fmt.Sprintf("doc%v.json", i), Project: "many", Content: `{}`})
+	}
+
+	var downloads int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/file/") && atomic.AddInt32(&downloads, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error": {"code": "server.error", "message": "server error"}}`))
+			return
+		}
+		bank.ServeHTTP(w, r)
+	}))
+	defer failing.Close()
+
+	jsb = Init(Config{Host: failing.URL, Keys: Keys{Public: "public", Private: "private"}})
+	if _, err := jsb.BackupWithOptions("many", io.Discard, BackupOptions{Concurrency: 1}); err == nil {
+		t.Error("Expected backup error")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&downloads); n > 2 {
+		t.Errorf("Expected downloads to stop after the failure, got %v", n)
+	}
+}
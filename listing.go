@@ -0,0 +1,100 @@
+package jsonbank
+
+import (
+	"fmt"
+	"github.com/jsonbankio/go-sdk/types"
+	"net/url"
+	"strconv"
+)
+
+// ListFolder - lists every document and folder directly inside a folder, all pages are fetched
+func (jsb *Instance) ListFolder(idOrPath string) (*types.FolderContents, *RequestError) {
+	return jsb.listAll(func(options types.ListOptions) (*types.FolderContents, *RequestError) {
+		return jsb.ListFolderPage(idOrPath, options)
+	})
+}
+
+// ListFolderPage - lists one page of the documents and folders directly inside a folder
+func (jsb *Instance) ListFolderPage(idOrPath string, options types.ListOptions) (*types.FolderContents, *RequestError) {
+	return jsb.listContents(fmt.Sprintf("/folder/%s/contents", idOrPath), options)
+}
+
+// ListProject - lists every document and folder at the root of a project, all pages are fetched
+func (jsb *Instance) ListProject(project string) (*types.FolderContents, *RequestError) {
+	return jsb.listAll(func(options types.ListOptions) (*types.FolderContents, *RequestError) {
+		return jsb.ListProjectPage(project, options)
+	})
+}
+
+// ListProjectPage - lists one page of the documents and folders at the root of a project
+func (jsb *Instance) ListProjectPage(project string, options types.ListOptions) (*types.FolderContents, *RequestError) {
+	if project == "" {
+		return nil, &RequestError{"bad_request", "Project is required"}
+	}
+
+	return jsb.listContents(fmt.Sprintf("/project/%s/contents", project), options)
+}
+
+// listAll - fetches every page of a listing and joins them
+func (jsb *Instance) listAll(list func(options types.ListOptions) (*types.FolderContents, *RequestError)) (*types.FolderContents, *RequestError) {
+	all := &types.FolderContents{Documents: []types.DocumentMeta{}, Folders: []types.Folder{}, Page: 1}
+
+	for page := 1; ; page++ {
+		contents, err := list(types.ListOptions{Page: page})
+		if err != nil {
+			return nil, err
+		}
+
+		all.Documents = append(all.Documents, contents.Documents...)
+		all.Folders = append(all.Folders, contents.Folders...)
+
+		// stop on empty pages in case the server keeps reporting more
+		if !contents.HasMore || len(contents.Documents)+len(contents.Folders) == 0 {
+			return all, nil
+		}
+	}
+}
+
+// listContents - gets a page of a listing
+func (jsb *Instance) listContents(path string, options types.ListOptions) (*types.FolderContents, *RequestError) {
+	if options.Page < 1 {
+		options.Page = 1
+	}
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(options.Page))
+	if options.Limit > 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+
+	req, err := jsb.makeRequest("GET", jsb.urls.v1+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsb.sendCoalescedRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	d := data.(map[string]interface{})
+	contents := &types.FolderContents{Documents: []types.DocumentMeta{}, Folders: []types.Folder{}, Page: options.Page}
+
+	if documents, ok := d["documents"].([]interface{}); ok {
+		for _, document := range documents {
+			contents.Documents = append(contents.Documents, *types.DataToDocumentMeta(document.(map[string]interface{})))
+		}
+	}
+
+	if folders, ok := d["folders"].([]interface{}); ok {
+		for _, folder := range folders {
+			contents.Folders = append(contents.Folders, *types.DataToFolder(folder.(map[string]interface{})))
+		}
+	}
+
+	if hasMore, ok := d["hasMore"].(bool); ok {
+		contents.HasMore = hasMore
+	}
+
+	return contents, nil
+}
@@ -0,0 +1,77 @@
+package jsonbank
+
+import (
+	"encoding/json"
+	"github.com/jsonbankio/go-sdk/types"
+)
+
+// MergePatch - applies a RFC 7396 json merge patch to a document
+// null values delete keys and objects are merged recursively, the document is not modified
+func MergePatch(document any, patch any) (any, *RequestError) {
+	target, err := normalizeJson(document)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := normalizeJson(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeValues(target, p), nil
+}
+
+// mergeValues - merges patch into target
+func mergeValues(target any, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergeValues(t[key], value)
+		}
+	}
+
+	return t
+}
+
+// MergeOwnDocument - merges partial into a document owned by the authenticated user using RFC 7396 semantics
+// returns the merged document
+func (jsb *Instance) MergeOwnDocument(idOrPath string, partial any) (any, *types.UpdatedDocument, *RequestError) {
+	content, err := jsb.getOwnContentExact(idOrPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged, err := MergePatch(content, partial)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newContent, jsonErr := json.Marshal(merged)
+	if jsonErr != nil {
+		return nil, nil, &RequestError{"json_error", jsonErr.Error()}
+	}
+
+	updated, err := jsb.UpdateOwnDocument(idOrPath, string(newContent))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// decode the result as configured by UseNumber
+	result, err := jsb.decodeContent(newContent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, updated, nil
+}
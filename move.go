@@ -0,0 +1,276 @@
+package jsonbank
+
+import (
+	"github.com/jsonbankio/go-sdk/types"
+	"path"
+	"strings"
+)
+
+// DeleteFolder - deletes a folder
+// with recursive, the documents and folders inside it are deleted first, otherwise the folder must be empty
+func (jsb *Instance) DeleteFolder(idOrPath string, recursive bool) (*types.DeletedFolder, *RequestError) {
+	result := &types.DeletedFolder{}
+
+	if recursive {
+		contents, err := jsb.ListFolder(idOrPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, document := range contents.Documents {
+			if _, err := jsb.DeleteDocument(document.Project + "/" + document.Path); err != nil {
+				return nil, err
+			}
+			result.Documents++
+		}
+
+		for _, folder := range contents.Folders {
+			deleted, err := jsb.DeleteFolder(folder.Project+"/"+folder.Path, true)
+			if err != nil {
+				return nil, err
+			}
+			result.Documents += deleted.Documents
+			result.Folders += deleted.Folders + 1
+		}
+	}
+
+	req, err := jsb.makePrivateRequest("DELETE", jsb.urls.v1+"/folder/"+idOrPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsb.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	d := data.(map[string]interface{})
+	result.Deleted = d["deleted"].(bool)
+
+	return result, nil
+}
+
+// RenameDocument - renames a document owned by the authenticated user
+// when the server cannot rename documents, the document is copied to the new name and the original is deleted
+func (jsb *Instance) RenameDocument(idOrPath string, name string) (*types.MovedDocument, *RequestError) {
+	if name == "" {
+		return nil, &RequestError{"bad_request", "Name is required"}
+	}
+
+	meta, err := jsb.GetOwnDocumentMeta(idOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsb.moveDocument(meta, "rename", map[string]string{"name": name}, parentPath(meta.Path), name)
+}
+
+// MoveDocument - moves a document owned by the authenticated user to a folder of its project, empty for the root
+// when the server cannot move documents, the document is copied to the folder and the original is deleted
+func (jsb *Instance) MoveDocument(idOrPath string, folder string) (*types.MovedDocument, *RequestError) {
+	meta, err := jsb.GetOwnDocumentMeta(idOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsb.moveDocument(meta, "move", map[string]string{"folder": folder}, folder, meta.Name)
+}
+
+// moveDocument - sends a rename or move request, falling back to copying the document
+func (jsb *Instance) moveDocument(meta *types.DocumentMeta, operation string, body any, folder string, name string) (*types.MovedDocument, *RequestError) {
+	req, err := jsb.makePrivateRequest("POST", jsb.urls.v1+"/file/"+meta.Id+"/"+operation, JsonToReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	data, supported, err := jsb.sendOptionalRequest(operation+"Document", req)
+	if err != nil {
+		return nil, err
+	}
+
+	if supported {
+		moved := types.DataToDocumentMeta(data.(map[string]interface{}))
+		return &types.MovedDocument{
+			Id:      moved.Id,
+			Name:    moved.Name,
+			Path:    moved.Path,
+			Project: moved.Project,
+			OldPath: meta.Path,
+		}, nil
+	}
+
+	// fallback: copy then delete
+	return jsb.copyDocument(meta, folder, name)
+}
+
+// copyDocument - moves a document by creating a copy and deleting the original
+// the copy is removed again when the original cannot be deleted
+func (jsb *Instance) copyDocument(meta *types.DocumentMeta, folder string, name string) (*types.MovedDocument, *RequestError) {
+	created, err := jsb.copyDocumentContent(meta, folder, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := jsb.DeleteDocument(meta.Id); err != nil {
+		_, _ = jsb.DeleteDocument(created.Id)
+		return nil, err
+	}
+
+	return &types.MovedDocument{
+		Id:      created.Id,
+		Name:    created.Name,
+		Path:    created.Path,
+		Project: created.Project,
+		OldPath: meta.Path,
+		Copied:  true,
+	}, nil
+}
+
+// copyDocumentContent - creates a copy of a document with the exact same content
+func (jsb *Instance) copyDocumentContent(meta *types.DocumentMeta, folder string, name string) (*types.NewDocument, *RequestError) {
+	content, err := jsb.GetOwnContentAsString(meta.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsb.createRawDocument(types.CreateDocumentBody{
+		Name:    name,
+		Project: meta.Project,
+		Folder:  folder,
+	}, []byte(content))
+}
+
+// RenameFolder - renames a folder
+// when the server cannot rename folders, a folder with the new name is created, the contents are moved into it
+// and the original folder is deleted
+func (jsb *Instance) RenameFolder(idOrPath string, name string) (*types.MovedFolder, *RequestError) {
+	if name == "" {
+		return nil, &RequestError{"bad_request", "Name is required"}
+	}
+
+	folder, err := jsb.GetFolder(idOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsb.moveFolder(folder, "rename", map[string]string{"name": name}, parentPath(folder.Path), name)
+}
+
+// MoveFolder - moves a folder into another folder of its project, empty for the root
+// when the server cannot move folders, the folder is recreated in the target and the original is deleted
+func (jsb *Instance) MoveFolder(idOrPath string, folder string) (*types.MovedFolder, *RequestError) {
+	source, err := jsb.GetFolder(idOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if folder == source.Path || strings.HasPrefix(folder, source.Path+"/") {
+		return nil, &RequestError{"bad_request", "A folder cannot be moved into itself"}
+	}
+
+	return jsb.moveFolder(source, "move", map[string]string{"folder": folder}, folder, source.Name)
+}
+
+// moveFolder - sends a rename or move request, falling back to copying the folder
+func (jsb *Instance) moveFolder(folder *types.Folder, operation string, body any, parent string, name string) (*types.MovedFolder, *RequestError) {
+	req, err := jsb.makePrivateRequest("POST", jsb.urls.v1+"/folder/"+folder.Id+"/"+operation, JsonToReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	data, supported, err := jsb.sendOptionalRequest(operation+"Folder", req)
+	if err != nil {
+		return nil, err
+	}
+
+	if supported {
+		moved := types.DataToFolder(data.(map[string]interface{}))
+		return &types.MovedFolder{
+			Id:      moved.Id,
+			Name:    moved.Name,
+			Path:    moved.Path,
+			Project: moved.Project,
+			OldPath: folder.Path,
+		}, nil
+	}
+
+	// fallback: copy then delete
+	created, err := jsb.copyFolder(folder, parent, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.MovedFolder{
+		Id:      created.Id,
+		Name:    created.Name,
+		Path:    created.Path,
+		Project: created.Project,
+		OldPath: folder.Path,
+		Copied:  true,
+	}, nil
+}
+
+// copyFolder - moves a folder by copying it with its contents into the parent and deleting the original
+// the original is only deleted once everything was copied, a partial copy is deleted again
+// when the original cannot be deleted the error names both the copy and the original
+func (jsb *Instance) copyFolder(folder *types.Folder, parent string, name string) (*types.NewFolder, *RequestError) {
+	created, err := jsb.copyFolderContents(folder, parent, name)
+	if err != nil {
+		if created != nil {
+			if _, rollbackErr := jsb.DeleteFolder(created.Id, true); rollbackErr != nil {
+				return nil, &RequestError{err.Code, err.Message + ", the partial copy in " + created.Project + "/" + created.Path + " could not be deleted"}
+			}
+		}
+		return nil, err
+	}
+
+	if _, err := jsb.DeleteFolder(folder.Id, true); err != nil {
+		return nil, &RequestError{err.Code, err.Message + ", the folder was copied to " + created.Project + "/" + created.Path +
+			" but the original " + folder.Project + "/" + folder.Path + " could not be deleted"}
+	}
+
+	return created, nil
+}
+
+// copyFolderContents - creates a copy of a folder and everything inside it
+// returns the created folder together with the error when copying its contents failed
+func (jsb *Instance) copyFolderContents(folder *types.Folder, parent string, name string) (*types.NewFolder, *RequestError) {
+	created, err := jsb.CreateFolder(types.CreateFolderBody{
+		Name:    name,
+		Project: folder.Project,
+		Folder:  parent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := jsb.ListFolder(folder.Id)
+	if err != nil {
+		return created, err
+	}
+
+	for i := range contents.Documents {
+		document := &contents.Documents[i]
+		if _, err := jsb.copyDocumentContent(document, created.Path, document.Name); err != nil {
+			return created, err
+		}
+	}
+
+	for i := range contents.Folders {
+		child := &contents.Folders[i]
+		if _, err := jsb.copyFolderContents(child, created.Path, child.Name); err != nil {
+			return created, err
+		}
+	}
+
+	return created, nil
+}
+
+// parentPath - returns the folder path of a document or folder path inside a project, empty for the root
+func parentPath(p string) string {
+	parent := path.Dir(p)
+	if parent == "." || parent == "/" {
+		return ""
+	}
+	return parent
+}
@@ -0,0 +1,269 @@
+package jsonbank
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jsonbankio/go-sdk/types"
+	"strconv"
+	"strings"
+)
+
+// ApplyPatch - applies RFC 6902 json patch operations to a document
+// the document is not modified, a patched copy is returned
+func ApplyPatch(document any, operations []types.PatchOperation) (any, *RequestError) {
+	// work on a copy in plain json types
+	result, err := normalizeJson(document)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, operation := range operations {
+		result, err = applyOperation(result, operation)
+		if err != nil {
+			err.Message = "Operation " + strconv.Itoa(i) + " (" + operation.Op + " " + operation.Path + "): " + err.Message
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// applyOperation - applies a single patch operation and returns the new document
+func applyOperation(document any, operation types.PatchOperation) (any, *RequestError) {
+	path, err := ParsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace":
+		value, err := normalizeJson(operation.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation.Op == "replace" {
+			if _, ok := valueAt(document, path); !ok {
+				return nil, &RequestError{"invalid_patch", "Path does not exist"}
+			}
+		}
+
+		return addValue(document, path, value, operation.Op == "replace")
+
+	case "remove":
+		return removeValue(document, path)
+
+	case "move", "copy":
+		from, err := ParsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, ok := valueAt(document, from)
+		if !ok {
+			return nil, &RequestError{"invalid_patch", "From path does not exist"}
+		}
+
+		if operation.Op == "copy" {
+			value, _ = normalizeJson(value)
+			return addValue(document, path, value, false)
+		}
+
+		// a value cannot be moved into one of its children
+		if operation.Path != operation.From && strings.HasPrefix(operation.Path, operation.From+"/") {
+			return nil, &RequestError{"invalid_patch", "Cannot move a value into itself"}
+		}
+
+		document, err = removeValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return addValue(document, path, value, false)
+
+	case "test":
+		value, ok := valueAt(document, path)
+		if !ok {
+			return nil, &RequestError{"patch_test_failed", "Path does not exist"}
+		}
+
+		expected, err := normalizeJson(operation.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !valuesEqual(value, expected) {
+			return nil, &RequestError{"patch_test_failed", "Value does not match"}
+		}
+
+		return document, nil
+	}
+
+	return nil, &RequestError{"invalid_patch", "Unknown operation " + operation.Op}
+}
+
+// updateParent - walks to the parent of the last token and lets update replace it
+func updateParent(node any, tokens []string, update func(parent any, token string) (any, *RequestError)) (any, *RequestError) {
+	if len(tokens) == 1 {
+		return update(node, tokens[0])
+	}
+
+	switch parent := node.(type) {
+	case map[string]any:
+		child, ok := parent[tokens[0]]
+		if !ok {
+			return nil, &RequestError{"invalid_patch", "Path does not exist"}
+		}
+
+		child, err := updateParent(child, tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		parent[tokens[0]] = child
+
+		return parent, nil
+	case []any:
+		index, ok := arrayIndex(tokens[0], len(parent)-1)
+		if !ok {
+			return nil, &RequestError{"invalid_patch", "Path does not exist"}
+		}
+
+		child, err := updateParent(parent[index], tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		parent[index] = child
+
+		return parent, nil
+	}
+
+	return nil, &RequestError{"invalid_patch", "Path does not exist"}
+}
+
+// addValue - adds or replaces the value at path
+func addValue(document any, path []string, value any, replace bool) (any, *RequestError) {
+	// the empty path replaces the whole document
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateParent(document, path, func(parent any, token string) (any, *RequestError) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			if token == "-" && !replace {
+				return append(node, value), nil
+			}
+
+			max := len(node)
+			if replace {
+				max--
+			}
+
+			index, ok := arrayIndex(token, max)
+			if !ok {
+				return nil, &RequestError{"invalid_patch", "Invalid array index " + token}
+			}
+
+			if replace {
+				node[index] = value
+				return node, nil
+			}
+
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+
+		return nil, &RequestError{"invalid_patch", "Path does not exist"}
+	})
+}
+
+// removeValue - removes the value at path
+func removeValue(document any, path []string) (any, *RequestError) {
+	if len(path) == 0 {
+		return nil, &RequestError{"invalid_patch", "Cannot remove the whole document"}
+	}
+
+	return updateParent(document, path, func(parent any, token string) (any, *RequestError) {
+		switch node := parent.(type) {
+		case map[string]any:
+			if _, ok := node[token]; !ok {
+				return nil, &RequestError{"invalid_patch", "Path does not exist"}
+			}
+			delete(node, token)
+			return node, nil
+		case []any:
+			index, ok := arrayIndex(token, len(node)-1)
+			if !ok {
+				return nil, &RequestError{"invalid_patch", "Invalid array index " + token}
+			}
+			return append(node[:index], node[index+1:]...), nil
+		}
+
+		return nil, &RequestError{"invalid_patch", "Path does not exist"}
+	})
+}
+
+// PatchOwnDocument - applies RFC 6902 json patch operations to a document owned by the authenticated user
+// when the server does not support patching, or when schemas, validation rules or canonical formatting apply to
+// writes, the document is fetched, patched locally and uploaded again
+func (jsb *Instance) PatchOwnDocument(idOrPath string, operations []types.PatchOperation) (*types.UpdatedDocument, *RequestError) {
+	// the server would write the patched document without the checks of the instance
+	if jsb.hasSchemas() || jsb.config.Validation.enabled() || jsb.config.Canonical.Enabled {
+		return jsb.patchLocally(idOrPath, operations)
+	}
+
+	body, _ := json.Marshal(operations)
+
+	req, err := jsb.makePrivateRequest("PATCH", jsb.urls.v1+"/file/"+idOrPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json-patch+json")
+
+	data, supported, err := jsb.sendOptionalRequest("patch", req)
+	if err != nil {
+		return nil, err
+	}
+
+	if supported {
+		d := data.(map[string]interface{})
+		return &types.UpdatedDocument{
+			Changed: d["changed"].(bool),
+		}, nil
+	}
+
+	// fallback: patch locally
+	return jsb.patchLocally(idOrPath, operations)
+}
+
+// patchLocally - reads a document, applies json patch operations and writes the result
+// only if the document was not changed in between, returns ConflictError otherwise
+func (jsb *Instance) patchLocally(idOrPath string, operations []types.PatchOperation) (*types.UpdatedDocument, *RequestError) {
+	// a fresh read never joins a request that started before the caller's own writes
+	current, err := jsb.getOwnContentAsString(idOrPath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := decodeJson([]byte(current))
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := ApplyPatch(content, operations)
+	if err != nil {
+		return nil, err
+	}
+
+	newContent, jsonErr := json.Marshal(patched)
+	if jsonErr != nil {
+		return nil, &RequestError{"json_error", jsonErr.Error()}
+	}
+
+	return jsb.UpdateOwnDocumentIfUnchanged(idOrPath, string(newContent), types.DocumentVersion{ContentHash: ContentHash(current)})
+}
@@ -0,0 +1,82 @@
+package jsonbank
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParsePointer - splits a RFC 6901 json pointer into unescaped reference tokens
+// the empty pointer "" references the whole document and returns no tokens
+func ParsePointer(pointer string) ([]string, *RequestError) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, &RequestError{"invalid_pointer", "Pointer must be empty or start with /: " + pointer}
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// MakePointer - builds a RFC 6901 json pointer from reference tokens
+func MakePointer(tokens ...string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+
+	return b.String()
+}
+
+// arrayIndex - parses a pointer token used as an array index
+// leading zeros are not allowed, max is the largest valid index
+func arrayIndex(token string, max int) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, false
+	}
+
+	return index, true
+}
+
+// valueAt - finds the value referenced by the tokens in a decoded json document
+func valueAt(document any, tokens []string) (any, bool) {
+	current := document
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			index, ok := arrayIndex(token, len(node)-1)
+			if !ok {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
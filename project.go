@@ -0,0 +1,137 @@
+package jsonbank
+
+import (
+	"fmt"
+	"github.com/jsonbankio/go-sdk/types"
+)
+
+// ListProjects - lists the projects of the authenticated user
+func (jsb *Instance) ListProjects() ([]types.Project, *RequestError) {
+	req, err := jsb.makePrivateRequest("GET", jsb.urls.v1+"/projects", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsb.sendCoalescedRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	d := data.(map[string]interface{})
+
+	projects := []types.Project{}
+	if list, ok := d["projects"].([]interface{}); ok {
+		for _, project := range list {
+			projects = append(projects, *types.DataToProject(project.(map[string]interface{})))
+		}
+	}
+
+	return projects, nil
+}
+
+// getProject - gets a project
+func (jsb *Instance) getProject(name string, includeStats bool) (*types.Project, *RequestError) {
+	url := fmt.Sprintf("/project/%s", name)
+
+	// add query params
+	if includeStats {
+		url += "?stats=true"
+	}
+
+	req, err := jsb.makePrivateRequest("GET", jsb.urls.v1+url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsb.sendCoalescedRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	p := types.DataToProject(data.(map[string]interface{}))
+	if !includeStats {
+		p.Stats = nil
+	}
+
+	return p, nil
+}
+
+// GetProject - gets a project
+func (jsb *Instance) GetProject(name string) (*types.Project, *RequestError) {
+	return jsb.getProject(name, false)
+}
+
+// GetProjectWithStats - gets a project with stats
+func (jsb *Instance) GetProjectWithStats(name string) (*types.Project, *RequestError) {
+	return jsb.getProject(name, true)
+}
+
+// CreateProject - creates a project
+func (jsb *Instance) CreateProject(body types.CreateProjectBody) (*types.NewProject, *RequestError) {
+	// name is required
+	if body.Name == "" {
+		return nil, &RequestError{"bad_request", "Name is required"}
+	}
+
+	req, err := jsb.makePrivateRequest("POST", jsb.urls.v1+"/projects", JsonToReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsb.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.NewProject{
+		Project: *types.DataToProject(data.(map[string]interface{})),
+		Exists:  false,
+	}, nil
+}
+
+// CreateProjectIfNotExists - creates a project if it does not exist
+// try to create the project, if it exists then fetch the project
+func (jsb *Instance) CreateProjectIfNotExists(body types.CreateProjectBody) (*types.NewProject, *RequestError) {
+	data, err := jsb.CreateProject(body)
+	if err != nil {
+		// if code is "name.exists" then fetch project
+		if err.Code == "name.exists" {
+			project, err := jsb.GetProject(body.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			return &types.NewProject{
+				Project: *project,
+				Exists:  true,
+			}, nil
+		}
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// DeleteProject - deletes a project and everything in it
+func (jsb *Instance) DeleteProject(name string) (*types.DeletedProject, *RequestError) {
+	if name == "" {
+		return nil, &RequestError{"bad_request", "Name is required"}
+	}
+
+	req, err := jsb.makePrivateRequest("DELETE", jsb.urls.v1+"/project/"+name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsb.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	d := data.(map[string]interface{})
+
+	return &types.DeletedProject{
+		Deleted: d["deleted"].(bool),
+	}, nil
+}
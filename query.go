@@ -0,0 +1,449 @@
+package jsonbank
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pathSelector - one step of a JSONPath expression
+type pathSelector struct {
+	recursive bool     // ..
+	wildcard  bool     // * or [*]
+	names     []string // .name or ['a','b']
+	indices   []int    // [0] or [0,-1]
+	slice     []*int   // [start:end:step]
+}
+
+// isPointer - checks if an expression is a json pointer rather than a JSONPath
+func isPointer(expression string) bool {
+	return !strings.HasPrefix(expression, "$")
+}
+
+// QueryContent - evaluates a RFC 6901 json pointer or a JSONPath expression on decoded content
+// pointers ("/a/0/b") return the referenced value, JSONPath expressions ("$.a[*].b") return a []any of every match.
+// supported JSONPath syntax: $, .name, ['name'], [index], [a,b], [start:end:step], *, and recursive descent (..)
+func QueryContent(content any, expression string) (any, *RequestError) {
+	if isPointer(expression) {
+		tokens, err := ParsePointer(expression)
+		if err != nil {
+			return nil, err
+		}
+
+		value, ok := valueAt(content, tokens)
+		if !ok {
+			return nil, &RequestError{"path_not_found", "No value at " + expression}
+		}
+
+		return value, nil
+	}
+
+	selectors, err := parseJsonPath(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []any{content}
+	for _, selector := range selectors {
+		matches = selector.apply(matches)
+	}
+
+	if len(matches) == 0 {
+		return nil, &RequestError{"path_not_found", "No value matches " + expression}
+	}
+
+	return matches, nil
+}
+
+// ValueAt - evaluates a json pointer or JSONPath expression and converts the result to T
+//
+//	port, err := jsonbank.ValueAt[int](content, "/server/port")
+func ValueAt[T any](content any, expression string) (T, *RequestError) {
+	var result T
+
+	value, err := QueryContent(content, expression)
+	if err != nil {
+		return result, err
+	}
+
+	if typed, ok := value.(T); ok {
+		return typed, nil
+	}
+
+	// convert through json, e.g. float64 to int or map to struct
+	data, jsonErr := json.Marshal(value)
+	if jsonErr == nil {
+		jsonErr = json.Unmarshal(data, &result)
+	}
+
+	if jsonErr != nil {
+		return result, &RequestError{"invalid_type", "Value at " + expression + " cannot be converted: " + jsonErr.Error()}
+	}
+
+	return result, nil
+}
+
+// parseJsonPath - parses a JSONPath expression into selectors
+func parseJsonPath(expression string) ([]pathSelector, *RequestError) {
+	invalid := func(reason string) *RequestError {
+		return &RequestError{"invalid_path", "Invalid JSONPath " + expression + ": " + reason}
+	}
+
+	var selectors []pathSelector
+	s := expression[1:]
+	for len(s) > 0 {
+		var selector pathSelector
+
+		switch {
+		case strings.HasPrefix(s, ".."):
+			selector.recursive = true
+			s = s[2:]
+			if strings.HasPrefix(s, "[") {
+				// handled as bracket below
+				break
+			}
+			fallthrough
+		case strings.HasPrefix(s, "."):
+			s = strings.TrimPrefix(s, ".")
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+
+			name := s[:end]
+			if name == "" {
+				return nil, invalid("empty name")
+			}
+
+			if name == "*" {
+				selector.wildcard = true
+			} else {
+				selector.names = []string{name}
+			}
+
+			s = s[end:]
+			selectors = append(selectors, selector)
+			continue
+		case !strings.HasPrefix(s, "["):
+			return nil, invalid("unexpected " + s)
+		}
+
+		end := closingBracket(s)
+		if end < 0 {
+			return nil, invalid("missing ]")
+		}
+
+		if err := selector.parseBracket(s[1:end]); err != nil {
+			return nil, invalid(err.Error())
+		}
+
+		s = s[end+1:]
+		selectors = append(selectors, selector)
+	}
+
+	return selectors, nil
+}
+
+// closingBracket - finds the ] closing the bracket at the start of s, ignoring brackets inside quotes
+func closingBracket(s string) int {
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseBracket - parses the content of a [...] selector
+func (selector *pathSelector) parseBracket(content string) error {
+	content = strings.TrimSpace(content)
+
+	if content == "*" {
+		selector.wildcard = true
+		return nil
+	}
+
+	// slice
+	if strings.Contains(content, ":") && !strings.ContainsAny(content, `'"`) {
+		parts := strings.Split(content, ":")
+		if len(parts) > 3 {
+			return errors.New("invalid slice")
+		}
+
+		selector.slice = make([]*int, 3)
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return errors.New("invalid slice")
+			}
+			selector.slice[i] = &n
+		}
+		return nil
+	}
+
+	for _, part := range splitUnion(content) {
+		part = strings.TrimSpace(part)
+		if len(part) >= 2 && (part[0] == '\'' || part[0] == '"') && part[len(part)-1] == part[0] {
+			selector.names = append(selector.names, part[1:len(part)-1])
+			continue
+		}
+
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return errors.New("invalid selector " + part)
+		}
+		selector.indices = append(selector.indices, n)
+	}
+
+	return nil
+}
+
+// splitUnion - splits a bracket content on commas outside of quotes
+func splitUnion(content string) []string {
+	var parts []string
+	var quote rune
+	start := 0
+	for i, c := range content {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ',':
+			parts = append(parts, content[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, content[start:])
+}
+
+// apply - evaluates the selector on every node
+func (selector *pathSelector) apply(nodes []any) []any {
+	if selector.recursive {
+		var all []any
+		for _, node := range nodes {
+			all = appendDescendants(all, node)
+		}
+		nodes = all
+	}
+
+	var result []any
+	for _, node := range nodes {
+		switch value := node.(type) {
+		case map[string]any:
+			if selector.wildcard {
+				for _, key := range sortedKeys(value) {
+					result = append(result, value[key])
+				}
+			}
+			for _, name := range selector.names {
+				if child, ok := value[name]; ok {
+					result = append(result, child)
+				}
+			}
+		case []any:
+			if selector.wildcard {
+				result = append(result, value...)
+			}
+			for _, index := range selector.indices {
+				if index < 0 {
+					index += len(value)
+				}
+				if index >= 0 && index < len(value) {
+					result = append(result, value[index])
+				}
+			}
+			if selector.slice != nil {
+				result = append(result, sliceArray(value, selector.slice)...)
+			}
+		}
+	}
+
+	return result
+}
+
+// appendDescendants - appends a node and all of its descendants in document order
+func appendDescendants(result []any, node any) []any {
+	result = append(result, node)
+	switch value := node.(type) {
+	case map[string]any:
+		for _, key := range sortedKeys(value) {
+			result = appendDescendants(result, value[key])
+		}
+	case []any:
+		for _, child := range value {
+			result = appendDescendants(result, child)
+		}
+	}
+	return result
+}
+
+// sliceArray - selects array elements with python-like [start:end:step] semantics
+func sliceArray(array []any, slice []*int) []any {
+	step := 1
+	if slice[2] != nil {
+		step = *slice[2]
+	}
+	if step == 0 {
+		return nil
+	}
+
+	length := len(array)
+	bound := func(n *int, fallback int) int {
+		if n == nil {
+			return fallback
+		}
+		i := *n
+		if i < 0 {
+			i += length
+		}
+		if i < 0 {
+			i = -1
+			if step > 0 {
+				i = 0
+			}
+		}
+		if i >= length {
+			i = length
+			if step < 0 {
+				i = length - 1
+			}
+		}
+		return i
+	}
+
+	var result []any
+	if step > 0 {
+		for i := bound(slice[0], 0); i < bound(slice[1], length); i += step {
+			result = append(result, array[i])
+		}
+	} else {
+		for i := bound(slice[0], length-1); i > bound(slice[1], -1); i += step {
+			result = append(result, array[i])
+		}
+	}
+	return result
+}
+
+// sortedKeys - returns the keys of a map in order
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// streamValueAt - decodes only the value referenced by the tokens, skipping everything else
+func streamValueAt(decoder *json.Decoder, tokens []string) (any, bool, error) {
+	if len(tokens) == 0 {
+		var value any
+		err := decoder.Decode(&value)
+		return value, err == nil, err
+	}
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, false, err
+			}
+
+			if key == tokens[0] {
+				return streamValueAt(decoder, tokens[1:])
+			}
+
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, false, err
+			}
+		}
+	case json.Delim('['):
+		for i := 0; decoder.More(); i++ {
+			if strconv.Itoa(i) == tokens[0] {
+				return streamValueAt(decoder, tokens[1:])
+			}
+
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	return nil, false, nil
+}
+
+// contentAt - evaluates an expression on a document, pointers are evaluated while streaming the document
+func contentAt(stream *ContentStream, expression string) (any, *RequestError) {
+	defer stream.Close()
+
+	if !isPointer(expression) {
+		var content any
+		if err := stream.Decoder().Decode(&content); err != nil && err != io.EOF {
+			return nil, &RequestError{"json_error", err.Error()}
+		}
+
+		return QueryContent(content, expression)
+	}
+
+	tokens, err := ParsePointer(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	value, found, decodeErr := streamValueAt(stream.Decoder(), tokens)
+	if decodeErr != nil {
+		return nil, &RequestError{"json_error", decodeErr.Error()}
+	}
+
+	if !found {
+		return nil, &RequestError{"path_not_found", "No value at " + expression}
+	}
+
+	return value, nil
+}
+
+// GetContentAt - gets the value at a json pointer or JSONPath expression in public content, see QueryContent
+func (jsb *Instance) GetContentAt(idOrPath string, expression string) (any, *RequestError) {
+	stream, err := jsb.GetContentStream(idOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return contentAt(stream, expression)
+}
+
+// GetOwnContentAt - gets the value at a json pointer or JSONPath expression in a document owned by the
+// authenticated user, see QueryContent
+func (jsb *Instance) GetOwnContentAt(idOrPath string, expression string) (any, *RequestError) {
+	stream, err := jsb.GetOwnContentStream(idOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return contentAt(stream, expression)
+}
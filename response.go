@@ -0,0 +1,55 @@
+package jsonbank
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Response - metadata of a http response received from jsonbank
+type Response struct {
+	StatusCode         int           // Http status code
+	Header             http.Header   // Response headers
+	RequestId          string        // Request id assigned by the server, useful for support tickets
+	RateLimitRemaining int           // Remaining requests in the current window, -1 if not sent by the server
+	Latency            time.Duration // Time between sending the request and receiving the response headers
+}
+
+// WithResponse - returns a copy of the instance that records the metadata of every response into res
+// the copy shares keys, memory and authentication with the original instance.
+// res is overwritten on every request, so the copy should not be used by concurrent goroutines.
+func (jsb *Instance) WithResponse(res *Response) *Instance {
+	c := *jsb
+	c.response = res
+	return &c
+}
+
+// newResponse - extracts metadata from a http response
+func newResponse(res *http.Response, latency time.Duration) *Response {
+	r := &Response{
+		StatusCode:         res.StatusCode,
+		Header:             res.Header,
+		RequestId:          res.Header.Get("X-Request-Id"),
+		RateLimitRemaining: -1,
+		Latency:            latency,
+	}
+
+	// rate limit header is sent with or without the X- prefix
+	remaining := res.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		remaining = res.Header.Get("RateLimit-Remaining")
+	}
+
+	if n, err := strconv.Atoi(remaining); err == nil {
+		r.RateLimitRemaining = n
+	}
+
+	return r
+}
+
+// recordResponse - stores response metadata if the instance was created with WithResponse
+func (jsb *Instance) recordResponse(res *Response) {
+	if jsb.response != nil && res != nil {
+		*jsb.response = *res
+	}
+}
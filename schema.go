@@ -0,0 +1,657 @@
+package jsonbank
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/jsonbankio/go-sdk/types"
+	"math/big"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxSchemaDepth - limits recursion of self referencing schemas
+const maxSchemaDepth = 256
+
+// Schema - a compiled json schema
+// supports a subset of draft 2020-12: type, enum, const, properties, patternProperties, additionalProperties,
+// required, dependentRequired, propertyNames, min/maxProperties, prefixItems, items, contains, min/maxItems,
+// uniqueItems, min/maxLength, pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf,
+// allOf, anyOf, oneOf, not, if/then/else, $defs and local $ref. Other keywords are ignored.
+type Schema struct {
+	root     any
+	patterns map[string]*regexp.Regexp
+}
+
+// CompileSchema - parses and checks a json schema
+func CompileSchema(schema string) (*Schema, *RequestError) {
+	var root any
+	if err := json.Unmarshal([]byte(schema), &root); err != nil {
+		return nil, &RequestError{"invalid_schema", err.Error()}
+	}
+
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compile(root); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// compile - compiles patterns and checks references of a schema and its subschemas
+func (s *Schema) compile(schema any) *RequestError {
+	node, ok := schema.(map[string]any)
+	if !ok {
+		if _, ok := schema.(bool); ok {
+			return nil
+		}
+		return &RequestError{"invalid_schema", "Schema must be an object or a boolean"}
+	}
+
+	if pattern, ok := node["pattern"].(string); ok {
+		if err := s.addPattern(pattern); err != nil {
+			return err
+		}
+	}
+
+	if ref, ok := node["$ref"].(string); ok {
+		if _, err := s.resolve(ref); err != nil {
+			return err
+		}
+	}
+
+	var children []any
+	for _, keyword := range []string{"additionalProperties", "items", "contains", "propertyNames", "not", "if", "then", "else"} {
+		if child, ok := node[keyword]; ok {
+			children = append(children, child)
+		}
+	}
+
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf", "prefixItems"} {
+		if list, ok := node[keyword].([]any); ok {
+			children = append(children, list...)
+		}
+	}
+
+	for _, keyword := range []string{"properties", "patternProperties", "$defs", "definitions", "dependentSchemas"} {
+		if schemas, ok := node[keyword].(map[string]any); ok {
+			for name, child := range schemas {
+				if keyword == "patternProperties" {
+					if err := s.addPattern(name); err != nil {
+						return err
+					}
+				}
+				children = append(children, child)
+			}
+		}
+	}
+
+	for _, child := range children {
+		if err := s.compile(child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addPattern - compiles a regular expression used by the schema
+func (s *Schema) addPattern(pattern string) *RequestError {
+	if _, ok := s.patterns[pattern]; ok {
+		return nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return &RequestError{"invalid_schema", "Invalid pattern " + pattern + ": " + err.Error()}
+	}
+	s.patterns[pattern] = re
+
+	return nil
+}
+
+// resolve - finds the subschema referenced by a local $ref
+func (s *Schema) resolve(ref string) (any, *RequestError) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, &RequestError{"invalid_schema", "Only local references are supported: " + ref}
+	}
+
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, &RequestError{"invalid_schema", "Invalid reference " + ref}
+	}
+
+	tokens, pointerErr := ParsePointer(fragment)
+	if pointerErr != nil {
+		return nil, &RequestError{"invalid_schema", "Invalid reference " + ref}
+	}
+
+	schema, ok := valueAt(s.root, tokens)
+	if !ok {
+		return nil, &RequestError{"invalid_schema", "Unresolved reference " + ref}
+	}
+
+	return schema, nil
+}
+
+// Validate - validates a decoded json value against the schema
+func (s *Schema) Validate(value any) []types.SchemaViolation {
+	v := &schemaValidator{schema: s}
+	v.validate(s.root, value, "", 0)
+	return v.violations
+}
+
+// ValidateJson - validates json content against the schema
+func (s *Schema) ValidateJson(content string) ([]types.SchemaViolation, *RequestError) {
+	var value any
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, &InvalidJsonError
+	}
+
+	return s.Validate(value), nil
+}
+
+// schemaValidator - collects violations of a single validation
+type schemaValidator struct {
+	schema     *Schema
+	violations []types.SchemaViolation
+}
+
+// fail - records a violation
+func (v *schemaValidator) fail(path string, keyword string, format string, args ...any) {
+	v.violations = append(v.violations, types.SchemaViolation{
+		Path:    path,
+		Keyword: keyword,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// matches - checks a value against a subschema without recording violations
+func (v *schemaValidator) matches(schema any, value any, path string, depth int) bool {
+	sub := &schemaValidator{schema: v.schema}
+	sub.validate(schema, value, path, depth)
+	return len(sub.violations) == 0
+}
+
+// validate - validates value against schema and records every violation
+func (v *schemaValidator) validate(schema any, value any, path string, depth int) {
+	if depth > maxSchemaDepth {
+		v.fail(path, "$ref", "schema recursion is too deep")
+		return
+	}
+
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(path, "false", "no value is allowed")
+		}
+		return
+	case map[string]any:
+		v.validateKeywords(s, value, path, depth)
+	}
+}
+
+func (v *schemaValidator) validateKeywords(s map[string]any, value any, path string, depth int) {
+	if ref, ok := s["$ref"].(string); ok {
+		if schema, err := v.schema.resolve(ref); err == nil {
+			v.validate(schema, value, path, depth+1)
+		}
+	}
+
+	if expected, ok := s["type"]; ok && !matchesType(expected, value) {
+		v.fail(path, "type", "expected %v, got %v", typeNames(expected), jsonType(value))
+		// other keywords would only repeat the type mismatch
+		return
+	}
+
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if valuesEqual(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "enum", "value is not one of the allowed values")
+		}
+	}
+
+	if expected, ok := s["const"]; ok && !valuesEqual(value, expected) {
+		v.fail(path, "const", "value must be %v", expected)
+	}
+
+	switch node := value.(type) {
+	case map[string]any:
+		v.validateObject(s, node, path, depth)
+	case []any:
+		v.validateArray(s, node, path, depth)
+	case string:
+		v.validateString(s, node, path)
+	case float64, json.Number:
+		v.validateNumber(s, value, path)
+	}
+
+	v.validateCombinators(s, value, path, depth)
+}
+
+func (v *schemaValidator) validateObject(s map[string]any, object map[string]any, path string, depth int) {
+	if required, ok := s["required"].([]any); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, exists := object[key]; !exists {
+					v.fail(path, "required", "missing required property %q", key)
+				}
+			}
+		}
+	}
+
+	if dependent, ok := s["dependentRequired"].(map[string]any); ok {
+		for key, names := range dependent {
+			if _, exists := object[key]; !exists {
+				continue
+			}
+			list, _ := names.([]any)
+			for _, name := range list {
+				if other, ok := name.(string); ok {
+					if _, exists := object[other]; !exists {
+						v.fail(path, "dependentRequired", "property %q is required when %q is present", other, key)
+					}
+				}
+			}
+		}
+	}
+
+	if n, ok := schemaInt(s["minProperties"]); ok && len(object) < n {
+		v.fail(path, "minProperties", "must have at least %v properties", n)
+	}
+	if n, ok := schemaInt(s["maxProperties"]); ok && len(object) > n {
+		v.fail(path, "maxProperties", "must have at most %v properties", n)
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	patternProperties, _ := s["patternProperties"].(map[string]any)
+	additional, hasAdditional := s["additionalProperties"]
+	propertyNames, hasPropertyNames := s["propertyNames"]
+
+	// visit keys in order so that violations are reported deterministically
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := object[key]
+		childPath := path + MakePointer(key)
+
+		if hasPropertyNames && !v.matches(propertyNames, key, childPath, depth+1) {
+			v.fail(childPath, "propertyNames", "property name %q is not allowed", key)
+		}
+
+		evaluated := false
+		if schema, ok := properties[key]; ok {
+			evaluated = true
+			v.validate(schema, child, childPath, depth+1)
+		}
+
+		for pattern, schema := range patternProperties {
+			if re := v.schema.patterns[pattern]; re != nil && re.MatchString(key) {
+				evaluated = true
+				v.validate(schema, child, childPath, depth+1)
+			}
+		}
+
+		if !evaluated && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(childPath, "additionalProperties", "additional property %q is not allowed", key)
+			} else {
+				v.validate(additional, child, childPath, depth+1)
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(s map[string]any, array []any, path string, depth int) {
+	if n, ok := schemaInt(s["minItems"]); ok && len(array) < n {
+		v.fail(path, "minItems", "must have at least %v items", n)
+	}
+	if n, ok := schemaInt(s["maxItems"]); ok && len(array) > n {
+		v.fail(path, "maxItems", "must have at most %v items", n)
+	}
+
+	if unique, ok := s["uniqueItems"].(bool); ok && unique {
+		for i := 0; i < len(array); i++ {
+			for j := i + 1; j < len(array); j++ {
+				if valuesEqual(array[i], array[j]) {
+					v.fail(path, "uniqueItems", "items %v and %v are equal", i, j)
+				}
+			}
+		}
+	}
+
+	prefixItems, _ := s["prefixItems"].([]any)
+	for i, item := range array {
+		itemPath := path + MakePointer(fmt.Sprint(i))
+		if i < len(prefixItems) {
+			v.validate(prefixItems[i], item, itemPath, depth+1)
+		} else if items, ok := s["items"]; ok {
+			if allowed, ok := items.(bool); ok && !allowed {
+				v.fail(itemPath, "items", "additional items are not allowed")
+			} else {
+				v.validate(items, item, itemPath, depth+1)
+			}
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		found := false
+		for i, item := range array {
+			if v.matches(contains, item, path+MakePointer(fmt.Sprint(i)), depth+1) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "contains", "must contain at least one matching item")
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(s map[string]any, value string, path string) {
+	length := utf8.RuneCountInString(value)
+	if n, ok := schemaInt(s["minLength"]); ok && length < n {
+		v.fail(path, "minLength", "must be at least %v characters long", n)
+	}
+	if n, ok := schemaInt(s["maxLength"]); ok && length > n {
+		v.fail(path, "maxLength", "must be at most %v characters long", n)
+	}
+
+	if pattern, ok := s["pattern"].(string); ok {
+		if re := v.schema.patterns[pattern]; re != nil && !re.MatchString(value) {
+			v.fail(path, "pattern", "must match pattern %v", pattern)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(s map[string]any, value any, path string) {
+	n, _ := numberValue(value)
+
+	compare := func(keyword string, failed func(cmp int) bool, message string) {
+		if limit, ok := numberValue(s[keyword]); ok && failed(n.Cmp(limit)) {
+			v.fail(path, keyword, message, limit)
+		}
+	}
+
+	compare("minimum", func(cmp int) bool { return cmp < 0 }, "must be >= %v")
+	compare("maximum", func(cmp int) bool { return cmp > 0 }, "must be <= %v")
+	compare("exclusiveMinimum", func(cmp int) bool { return cmp <= 0 }, "must be > %v")
+	compare("exclusiveMaximum", func(cmp int) bool { return cmp >= 0 }, "must be < %v")
+
+	// decimal numbers are divided exactly, 19.99 is a multiple of 0.01
+	if divisor, ok := ratValue(s["multipleOf"]); ok && divisor.Sign() > 0 {
+		if dividend, ok := ratValue(value); ok && !new(big.Rat).Quo(dividend, divisor).IsInt() {
+			v.fail(path, "multipleOf", "must be a multiple of %v", divisor.RatString())
+		}
+	}
+}
+
+func (v *schemaValidator) validateCombinators(s map[string]any, value any, path string, depth int) {
+	if allOf, ok := s["allOf"].([]any); ok {
+		for _, schema := range allOf {
+			v.validate(schema, value, path, depth+1)
+		}
+	}
+
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		matched := false
+		for _, schema := range anyOf {
+			if v.matches(schema, value, path, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "anyOf", "must match at least one schema")
+		}
+	}
+
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		matched := 0
+		for _, schema := range oneOf {
+			if v.matches(schema, value, path, depth+1) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(path, "oneOf", "must match exactly one schema, matched %v", matched)
+		}
+	}
+
+	if not, ok := s["not"]; ok && v.matches(not, value, path, depth+1) {
+		v.fail(path, "not", "must not match schema")
+	}
+
+	if condition, ok := s["if"]; ok {
+		if v.matches(condition, value, path, depth+1) {
+			if then, ok := s["then"]; ok {
+				v.validate(then, value, path, depth+1)
+			}
+		} else if otherwise, ok := s["else"]; ok {
+			v.validate(otherwise, value, path, depth+1)
+		}
+	}
+}
+
+// jsonType - returns the json schema type of a decoded value
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	return "unknown"
+}
+
+// matchesType - checks a value against the type keyword, a type name or a list of type names
+func matchesType(expected any, value any) bool {
+	names, ok := expected.([]any)
+	if !ok {
+		names = []any{expected}
+	}
+
+	actual := jsonType(value)
+	for _, name := range names {
+		if name == actual {
+			return true
+		}
+
+		if name == "integer" && actual == "number" {
+			if n, ok := numberValue(value); ok && n.IsInt() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// typeNames - formats the type keyword for messages
+func typeNames(expected any) string {
+	names, ok := expected.([]any)
+	if !ok {
+		return fmt.Sprint(expected)
+	}
+
+	s := make([]string, len(names))
+	for i, name := range names {
+		s[i] = fmt.Sprint(name)
+	}
+	return strings.Join(s, " or ")
+}
+
+// schemaInt - reads a non-negative integer keyword
+func schemaInt(value any) (int, bool) {
+	n, ok := value.(float64)
+	if !ok || n < 0 {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// ========== Instance schemas ==========
+
+// schemaRule - a schema attached to documents matching target
+type schemaRule struct {
+	target string
+	schema *Schema
+}
+
+// schemaRegistry - schemas attached to an instance, shared by copies of the instance
+type schemaRegistry struct {
+	mu    sync.RWMutex
+	rules []schemaRule
+}
+
+// schemaRegistryInit - guards the registry of instances not created by Init, which is created by AddSchema
+var schemaRegistryInit sync.Mutex
+
+// registry - returns the schema registry of the instance, nil when no schema was added
+// Init creates the registry, so only zero value instances are created lazily. Copies of such an instance
+// made before its first AddSchema do not share its schemas.
+func (jsb *Instance) registry(create bool) *schemaRegistry {
+	schemaRegistryInit.Lock()
+	defer schemaRegistryInit.Unlock()
+
+	if jsb.schemas == nil && create {
+		jsb.schemas = &schemaRegistry{}
+	}
+	return jsb.schemas
+}
+
+// AddSchema - validates every document written to target against schema before it is sent
+// target is a project ("my-project"), a folder ("my-project/configs") or a path.Match pattern ("my-project/*.json")
+func (jsb *Instance) AddSchema(target string, schema *Schema) {
+	registry := jsb.registry(true)
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.rules = append(registry.rules, schemaRule{strings.Trim(target, "/"), schema})
+}
+
+// schemasFor - returns every schema attached to a document path
+func (jsb *Instance) schemasFor(documentPath string) []*Schema {
+	registry := jsb.registry(false)
+	if registry == nil {
+		return nil
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	var schemas []*Schema
+	for _, rule := range registry.rules {
+		matched, _ := path.Match(rule.target, documentPath)
+		if matched || documentPath == rule.target || strings.HasPrefix(documentPath, rule.target+"/") {
+			schemas = append(schemas, rule.schema)
+		}
+	}
+
+	return schemas
+}
+
+// hasSchemas - checks if any schema is attached to the instance
+func (jsb *Instance) hasSchemas() bool {
+	registry := jsb.registry(false)
+	if registry == nil {
+		return false
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	return len(registry.rules) > 0
+}
+
+// ownDocumentPath - resolves an id or path to the full path of a document, e.g. project/folder/name.json
+func (jsb *Instance) ownDocumentPath(idOrPath string) (string, *RequestError) {
+	// paths always contain the project
+	if strings.Contains(idOrPath, "/") {
+		return strings.Trim(idOrPath, "/"), nil
+	}
+
+	meta, err := jsb.GetOwnDocumentMeta(idOrPath)
+	if err != nil {
+		return "", err
+	}
+
+	return meta.Project + "/" + meta.Path, nil
+}
+
+// validateSchemas - validates content against every schema attached to the document path
+func (jsb *Instance) validateSchemas(documentPath string, content []byte) ([]types.SchemaViolation, *RequestError) {
+	schemas := jsb.schemasFor(documentPath)
+	if len(schemas) == 0 {
+		return nil, nil
+	}
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, &InvalidJsonError
+	}
+
+	var violations []types.SchemaViolation
+	for _, schema := range schemas {
+		violations = append(violations, schema.Validate(value)...)
+	}
+
+	return violations, nil
+}
+
+// schemaError - converts violations into a RequestError listing each invalid path
+func schemaError(violations []types.SchemaViolation) *RequestError {
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		location := violation.Path
+		if location == "" {
+			location = "/"
+		}
+		messages[i] = location + ": " + violation.Message
+	}
+
+	return &RequestError{"schema_validation", "Content does not match schema: " + strings.Join(messages, "; ")}
+}
+
+// ValidateDocument - validates the content of a document owned by the authenticated user
+// against every schema attached to its path
+func (jsb *Instance) ValidateDocument(idOrPath string) ([]types.SchemaViolation, *RequestError) {
+	documentPath, err := jsb.ownDocumentPath(idOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := jsb.GetOwnContentAsString(idOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsb.validateSchemas(documentPath, []byte(content))
+}
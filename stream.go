@@ -0,0 +1,151 @@
+package jsonbank
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// ContentStream - content of a document read incrementally from the response body
+// the stream must be closed after use
+type ContentStream struct {
+	io.ReadCloser
+	useNumber bool
+}
+
+// Decoder - returns a json decoder positioned at the root of the document
+// numbers are decoded as json.Number when the instance was configured with UseNumber
+func (s *ContentStream) Decoder() *json.Decoder {
+	decoder := json.NewDecoder(s)
+	if s.useNumber {
+		decoder.UseNumber()
+	}
+	return decoder
+}
+
+// Elements - returns an iterator over the elements of a document whose root is an array
+func (s *ContentStream) Elements() *ElementIterator {
+	return &ElementIterator{decoder: s.Decoder(), index: -1, useNumber: s.useNumber}
+}
+
+// ElementIterator - iterates over the elements of a top-level json array one at a time
+//
+//	it := stream.Elements()
+//	for it.Next() {
+//		var item Item
+//		if err := it.Decode(&item); err != nil { ... }
+//	}
+//	if it.Err() != nil { ... }
+type ElementIterator struct {
+	decoder   *json.Decoder
+	useNumber bool
+	started   bool
+	done      bool
+	index     int
+	raw       json.RawMessage
+	err       *RequestError
+}
+
+// Next - advances to the next element, returns false when the array ends or an error occurs
+func (it *ElementIterator) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+
+	// consume the opening bracket
+	if !it.started {
+		it.started = true
+		token, err := it.decoder.Token()
+		if err != nil {
+			it.err = &RequestError{"json_error", err.Error()}
+			return false
+		}
+
+		if token != json.Delim('[') {
+			it.err = &RequestError{"json_error", "Content is not an array"}
+			return false
+		}
+	}
+
+	if !it.decoder.More() {
+		it.done = true
+		it.raw = nil
+
+		// consume the closing bracket
+		if _, err := it.decoder.Token(); err != nil {
+			it.err = &RequestError{"json_error", err.Error()}
+		}
+		return false
+	}
+
+	var raw json.RawMessage
+	if err := it.decoder.Decode(&raw); err != nil {
+		it.err = &RequestError{"json_error", err.Error()}
+		return false
+	}
+
+	it.raw = raw
+	it.index++
+
+	return true
+}
+
+// Index - position of the current element in the array
+func (it *ElementIterator) Index() int {
+	return it.index
+}
+
+// Raw - raw json of the current element
+func (it *ElementIterator) Raw() json.RawMessage {
+	return it.raw
+}
+
+// Decode - decodes the current element into v
+func (it *ElementIterator) Decode(v any) *RequestError {
+	decoder := json.NewDecoder(bytes.NewReader(it.raw))
+	if it.useNumber {
+		decoder.UseNumber()
+	}
+
+	if err := decoder.Decode(v); err != nil {
+		return &RequestError{"json_error", err.Error()}
+	}
+
+	return nil
+}
+
+// Err - returns the error that stopped the iteration, if any
+func (it *ElementIterator) Err() *RequestError {
+	return it.err
+}
+
+// openContentStream - sends the request and wraps the response body in a ContentStream
+func (jsb *Instance) openContentStream(req *http.Request) (*ContentStream, *RequestError) {
+	res, err := jsb.openRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContentStream{res.Body, jsb.config.UseNumber}, nil
+}
+
+// GetContentStream - get public content from jsonbank as a stream
+func (jsb *Instance) GetContentStream(idOrPath string) (*ContentStream, *RequestError) {
+	req, err := jsb.makePublicRequest("GET", jsb.urls.public+"/f/"+idOrPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsb.openContentStream(req)
+}
+
+// GetOwnContentStream - gets the content of a document owned by the authenticated user as a stream
+func (jsb *Instance) GetOwnContentStream(idOrPath string) (*ContentStream, *RequestError) {
+	req, err := jsb.makeRequest("GET", jsb.urls.v1+"/file/"+idOrPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsb.openContentStream(req)
+}
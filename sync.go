@@ -0,0 +1,404 @@
+package jsonbank
+
+import (
+	"context"
+	"github.com/jsonbankio/go-sdk/types"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SyncOptions - options of SyncDirectory
+type SyncOptions struct {
+	Include     []string // Glob patterns of files to sync, files of every supported format by default, only matching remote documents are deleted
+	Exclude     []string // Glob patterns of files and directories to leave out, remote ones are never deleted
+	Delete      bool     // Delete remote documents and folders that are not synced from a local file
+	DryRun      bool     // Report the changes without making them
+	Concurrency int      // Maximum number of concurrent requests, 4 by default
+}
+
+// SyncReport - changes made by SyncDirectory, paths are full remote paths starting with the project
+type SyncReport struct {
+	CreatedFolders []string
+	Created        []string
+	Updated        []string
+	Unchanged      []string
+	Deleted        []string
+	DeletedFolders []string
+	Errors         []SyncError
+	DryRun         bool
+}
+
+// SyncError - a document or folder that could not be synced
+type SyncError struct {
+	Path string
+	Err  *RequestError
+}
+
+// syncFile - a local file and the document it is synced to
+type syncFile struct {
+	localPath string
+	format    string
+	remote    string // path of the document relative to the synced folder
+}
+
+// SyncDirectory - mirrors a local directory into a folder of a project, empty for the project root
+// missing folders are created, new files uploaded and changed files updated, files are compared by content hash.
+// Patterns are matched against the slash separated path relative to localDir and, when they contain no slash,
+// against the file name. Files in other formats than json are converted and stored as .json documents.
+func (jsb *Instance) SyncDirectory(localDir string, project string, folder string, options SyncOptions) (*SyncReport, *RequestError) {
+	if project == "" {
+		return nil, &RequestError{"bad_request", "Project is required"}
+	}
+
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+
+	folder = strings.Trim(folder, "/")
+	root := project
+	if folder != "" {
+		root += "/" + folder
+	}
+
+	report := &SyncReport{DryRun: options.DryRun}
+
+	files, err := localSyncFiles(localDir, options)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, folders, protected, err := jsb.remoteSyncEntries(root, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// folders needed by the local files, parents first
+	needed := map[string]bool{}
+	for _, file := range files {
+		for dir := parentPath(file.remote); dir != ""; dir = parentPath(dir) {
+			needed[dir] = true
+		}
+	}
+
+	if folder != "" && !folders[""] {
+		needed[""] = true
+	}
+
+	for _, dir := range sortedSet(needed) {
+		if folders[dir] {
+			continue
+		}
+
+		target := strings.TrimSuffix(root+"/"+dir, "/")
+		if !options.DryRun {
+			if _, err := jsb.EnsureFolderPath(target); err != nil {
+				return nil, err
+			}
+		}
+		report.CreatedFolders = append(report.CreatedFolders, target)
+	}
+
+	// upload documents concurrently
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, options.Concurrency)
+
+	for _, file := range files {
+		file := file
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			target := root + "/" + file.remote
+			_, exists := documents[file.remote]
+			list, err := jsb.syncFile(file, project, folder, exists, options.DryRun)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Errors = append(report.Errors, SyncError{target, err})
+				return
+			}
+
+			switch list {
+			case "created":
+				report.Created = append(report.Created, target)
+			case "updated":
+				report.Updated = append(report.Updated, target)
+			default:
+				report.Unchanged = append(report.Unchanged, target)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// delete remote extras
+	if options.Delete {
+		local := map[string]bool{}
+		for _, file := range files {
+			local[file.remote] = true
+		}
+
+		// documents are deleted one at a time, excluded ones are never listed
+		// with include patterns, documents they do not match are kept with their folders
+		for _, remote := range sortedKeys(documents) {
+			if local[remote] {
+				continue
+			}
+
+			if len(options.Include) > 0 && !matchesAny(options.Include, remote) {
+				protectParents(protected, remote)
+				continue
+			}
+
+			target := root + "/" + remote
+			if !options.DryRun {
+				if _, err := jsb.DeleteDocument(target); err != nil {
+					report.Errors = append(report.Errors, SyncError{target, err})
+					continue
+				}
+			}
+			report.Deleted = append(report.Deleted, target)
+		}
+
+		// folders are deleted once empty, children first, folders holding excluded entries are kept
+		dirs := sortedSet(folders)
+		for i := len(dirs) - 1; i >= 0; i-- {
+			dir := dirs[i]
+			if dir == "" || needed[dir] || protected[dir] {
+				continue
+			}
+
+			target := root + "/" + dir
+			if !options.DryRun {
+				if _, err := jsb.DeleteFolder(target, false); err != nil {
+					report.Errors = append(report.Errors, SyncError{target, err})
+					continue
+				}
+			}
+			report.DeletedFolders = append(report.DeletedFolders, target)
+		}
+	}
+
+	for _, list := range [][]string{report.Created, report.Updated, report.Unchanged, report.DeletedFolders} {
+		sort.Strings(list)
+	}
+	sort.Slice(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Path < report.Errors[j].Path
+	})
+
+	return report, nil
+}
+
+// syncFile - uploads a file to a folder of a project when it is new or changed, returns created, updated or unchanged
+func (jsb *Instance) syncFile(file syncFile, project string, folder string, exists bool, dryRun bool) (string, *RequestError) {
+	data, readErr := os.ReadFile(file.localPath)
+	if readErr != nil {
+		return "", &RequestError{"invalid_file", "Could not read file " + file.localPath}
+	}
+
+	content, err := ConvertToJson(data, file.format)
+	if err != nil {
+		return "", err
+	}
+
+	if err := jsb.validateContent(content); err != nil {
+		return "", err
+	}
+
+	// content is compared as it would be written
+	content, err = jsb.canonicalizeContent(content)
+	if err != nil {
+		return "", err
+	}
+
+	document := types.CreateDocumentBody{
+		Name:    path.Base(file.remote),
+		Project: project,
+		Folder:  strings.Trim(folder+"/"+parentPath(file.remote), "/"),
+	}
+
+	if !exists {
+		if !dryRun {
+			_, err := jsb.createDocument(document, content)
+			if err != nil {
+				return "", err
+			}
+		}
+		return "created", nil
+	}
+
+	target := MakeDocumentPath(document)
+	current, err := jsb.GetOwnContentAsString(target)
+	if err != nil {
+		return "", err
+	}
+
+	if ContentHash(current) == ContentHash(string(content)) {
+		return "unchanged", nil
+	}
+
+	if !dryRun {
+		if _, err := jsb.updateOwnDocument(target, string(content), nil); err != nil {
+			return "", err
+		}
+	}
+
+	return "updated", nil
+}
+
+// localSyncFiles - finds the files of a directory to sync
+func localSyncFiles(localDir string, options SyncOptions) ([]syncFile, *RequestError) {
+	var files []syncFile
+	seen := map[string]string{}
+
+	walkErr := filepath.WalkDir(localDir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(localDir, p)
+		rel = filepath.ToSlash(rel)
+		if rel == "." || rel == ManifestFile {
+			return nil
+		}
+
+		if matchesAny(options.Exclude, rel) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() || !isSyncedFile(rel, options) {
+			return nil
+		}
+
+		file := syncFile{localPath: p, format: FormatFromPath(rel), remote: rel}
+		if file.format != FormatJson {
+			file.remote = jsonName(rel)
+		}
+
+		// e.g. app.yaml and app.json
+		if other, ok := seen[file.remote]; ok {
+			return &RequestError{"conflict", other + " and " + rel + " are both synced to " + file.remote}
+		}
+		seen[file.remote] = rel
+
+		files = append(files, file)
+		return nil
+	})
+
+	if walkErr != nil {
+		if err, ok := walkErr.(*RequestError); ok {
+			return nil, err
+		}
+		return nil, &RequestError{"invalid_directory", walkErr.Error()}
+	}
+
+	return files, nil
+}
+
+// remoteSyncEntries - lists the documents and folders below the synced folder by relative path
+// the synced folder itself is included as "" when it exists. Excluded entries are left out,
+// the folders containing them are returned as protected.
+func (jsb *Instance) remoteSyncEntries(root string, options SyncOptions) (map[string]any, map[string]bool, map[string]bool, *RequestError) {
+	documents := map[string]any{}
+	folders := map[string]bool{}
+	protected := map[string]bool{}
+
+	var failure *RequestError
+	walkErr := jsb.Walk(context.Background(), root, func(entry *types.WalkEntry, err *RequestError) error {
+		if err != nil {
+			// nothing has been synced yet
+			if err.Code == "notFound" && entry.Path == root {
+				return nil
+			}
+			failure = err
+			return err
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(entry.Path, root), "/")
+		if rel != "" && matchesAny(options.Exclude, rel) {
+			protectParents(protected, rel)
+
+			if entry.IsFolder() {
+				return SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsFolder() {
+			folders[rel] = true
+		} else {
+			documents[rel] = entry.Document
+		}
+		return nil
+	})
+
+	if failure != nil {
+		return nil, nil, nil, failure
+	}
+	if walkErr != nil {
+		return nil, nil, nil, &RequestError{"sync_error", walkErr.Error()}
+	}
+
+	return documents, folders, protected, nil
+}
+
+// protectParents - marks the folders containing a kept entry so they are not deleted
+func protectParents(protected map[string]bool, rel string) {
+	for dir := parentPath(rel); ; dir = parentPath(dir) {
+		protected[dir] = true
+		if dir == "" {
+			break
+		}
+	}
+}
+
+// isSyncedFile - checks if a file is synced with the include patterns or, without patterns, by its format
+func isSyncedFile(rel string, options SyncOptions) bool {
+	if len(options.Include) > 0 {
+		return matchesAny(options.Include, rel)
+	}
+
+	switch strings.ToLower(path.Ext(rel)) {
+	case ".json", ".jsonc", ".json5", ".yaml", ".yml", ".toml", ".csv":
+		return true
+	}
+	return false
+}
+
+// matchesAny - checks if a slash separated path matches a pattern, patterns without a slash also match the name
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortedSet - returns the keys of a set in order
+func sortedSet(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,164 @@
+package jsonbank
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"math"
+	"strings"
+	"time"
+)
+
+// tomlToJson - converts a toml document to json, keys are sorted
+// dates and times are converted to strings
+func tomlToJson(content []byte) ([]byte, *RequestError) {
+	var value map[string]any
+	if _, err := toml.Decode(string(content), &value); err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			line, column := position(content, int64(parseErr.Position.Start))
+			return nil, &RequestError{"invalid_toml", fmt.Sprintf("line %v, column %v: %v", line, column, parseErr.Message)}
+		}
+		return nil, &RequestError{"invalid_toml", err.Error()}
+	}
+
+	normalized, err := tomlToJsonValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, normalized, "", 0); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// tomlToJsonValue - converts a decoded toml value to plain json types
+func tomlToJsonValue(value any) (any, *RequestError) {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, child := range v {
+			c, err := tomlToJsonValue(child)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = c
+		}
+		return result, nil
+	case []map[string]any:
+		result := make([]any, len(v))
+		for i, child := range v {
+			c, err := tomlToJsonValue(child)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = c
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, child := range v {
+			c, err := tomlToJsonValue(child)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = c
+		}
+		return result, nil
+	case int64:
+		return json.Number(fmt.Sprint(v)), nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, &RequestError{"invalid_toml", fmt.Sprintf("%v cannot be represented in json", v)}
+		}
+		return v, nil
+	case time.Time:
+		return tomlTime(v), nil
+	}
+
+	return value, nil
+}
+
+// tomlTime - formats a toml date, time or datetime the way it is written in toml
+// local values are decoded with a location named after their kind
+func tomlTime(t time.Time) string {
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
+// jsonToToml - converts json content to toml, the root of the content must be an object
+func jsonToToml(content []byte) ([]byte, *RequestError) {
+	value, err := decodeJson(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := value.(map[string]any); !ok {
+		return nil, &RequestError{"unsupported_content", "Only objects can be converted to toml"}
+	}
+
+	converted, err := jsonToTomlValue(value, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(converted); err != nil {
+		return nil, &RequestError{"toml_error", err.Error()}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// jsonToTomlValue - converts a decoded json value to types the toml encoder accepts
+func jsonToTomlValue(value any, path string) (any, *RequestError) {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, child := range v {
+			c, err := jsonToTomlValue(child, path+MakePointer(key))
+			if err != nil {
+				return nil, err
+			}
+			result[key] = c
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, child := range v {
+			c, err := jsonToTomlValue(child, fmt.Sprintf("%v/%v", path, i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = c
+		}
+		return result, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		// toml integers are 64 bit, larger integers would lose precision
+		f, err := v.Float64()
+		if err != nil || !strings.ContainsAny(v.String(), ".eE") {
+			return nil, &RequestError{"unsupported_content", "Number at " + path + " cannot be represented in toml"}
+		}
+		return f, nil
+	case nil:
+		return nil, &RequestError{"unsupported_content", "Null at " + path + " cannot be represented in toml"}
+	}
+
+	return value, nil
+}
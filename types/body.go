@@ -1,10 +1,16 @@
 package types
 
+import "io/fs"
+
 type CreateDocumentBody struct {
 	Name    string `json:"name"`
 	Project string `json:"project"`
 	Folder  string `json:"folder"`
 	Content string `json:"content"`
+	// optional format of Content, e.g. jsonc or json5, converted to json before it is written
+	Format string `json:"-"`
+	// create missing folders of Folder before creating the document
+	CreateFolders bool `json:"-"`
 }
 
 type CreateFolderBody struct {
@@ -13,9 +19,35 @@ type CreateFolderBody struct {
 	Folder  string `json:"folder"`
 }
 
+type CreateProjectBody struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Public      bool   `json:"public"`
+}
+
 type UploadDocumentBody struct {
 	FilePath string `json:"file"`
 	Project  string `json:"project"`
 	Name     string `json:"name"`
 	Folder   string `json:"folder"`
+	// optional file system to read FilePath from, e.g. an embed.FS
+	FS fs.FS `json:"-"`
+	// optional format of the file, detected from its extension when empty
+	Format string `json:"-"`
+	// create missing folders of Folder before creating the document
+	CreateFolders bool `json:"-"`
+}
+
+// PatchOperation - a RFC 6902 json patch operation
+type PatchOperation struct {
+	Op    string `json:"op"`             // add, remove, replace, move, copy or test
+	Path  string `json:"path"`           // json pointer to the target location
+	From  string `json:"from,omitempty"` // json pointer to the source location of move and copy
+	Value any    `json:"value"`
+}
+
+// ListOptions - pagination of folder and project listings
+type ListOptions struct {
+	Page  int // page to get, starting at 1
+	Limit int // maximum number of documents and folders per page, 0 for the server default
 }
@@ -1,5 +1,7 @@
 package types
 
+import "encoding/json"
+
 type AuthenticatedKey struct {
 	Title    string   `json:"title"`
 	Projects []string `json:"projects,omitempty"`
@@ -20,8 +22,31 @@ type NewDocument struct {
 }
 
 type FolderStats struct {
-	Documents float64 `json:"documents"`
-	Folders   float64 `json:"folders"`
+	Documents int64 `json:"documents"`
+	Folders   int64 `json:"folders"`
+}
+
+// DataToFolderStats - converts folder stats returned by the server
+func DataToFolderStats(data map[string]interface{}) *FolderStats {
+	return &FolderStats{
+		Documents: dataToInt64(data["documents"]),
+		Folders:   dataToInt64(data["folders"]),
+	}
+}
+
+// dataToInt64 - converts a number decoded with or without json.Decoder.UseNumber to int64
+func dataToInt64(value any) int64 {
+	switch n := value.(type) {
+	case float64:
+		return int64(n)
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+		f, _ := n.Float64()
+		return int64(f)
+	}
+	return 0
 }
 
 type Folder struct {
@@ -36,6 +61,50 @@ type Folder struct {
 	Stats *FolderStats `json:"stats,omitempty"`
 }
 
+// DataToFolder - converts a folder returned by the server
+func DataToFolder(data map[string]interface{}) *Folder {
+	f := &Folder{
+		Id:        data["id"].(string),
+		Name:      data["name"].(string),
+		Path:      data["path"].(string),
+		Project:   data["project"].(string),
+		CreatedAt: data["createdAt"].(string),
+		UpdatedAt: data["updatedAt"].(string),
+	}
+
+	// check if parent folder exists
+	if parent, ok := data["parentFolder"].(string); ok {
+		f.ParentFolder = parent
+	}
+
+	if stats, ok := data["stats"].(map[string]interface{}); ok {
+		f.Stats = DataToFolderStats(stats)
+	}
+
+	return f
+}
+
+// FolderContents - documents and folders directly inside a folder or at the root of a project
+type FolderContents struct {
+	Documents []DocumentMeta `json:"documents"`
+	Folders   []Folder       `json:"folders"`
+	Page      int            `json:"page"`    // page of the listing, starting at 1
+	HasMore   bool           `json:"hasMore"` // more pages follow
+}
+
+// WalkEntry - a folder or document visited by Walk
+type WalkEntry struct {
+	Path     string        // full path, starting with the project
+	Folder   *Folder       // set for folders, nil for documents and the root of a project
+	Document *DocumentMeta // set for documents
+	Content  any           // content of documents when loaded
+}
+
+// IsFolder - checks if the entry is a folder or the root of a project
+func (entry *WalkEntry) IsFolder() bool {
+	return entry.Document == nil
+}
+
 // NewFolder extends Folder
 type NewFolder struct {
 	Folder `json:",inline"`
@@ -43,8 +112,8 @@ type NewFolder struct {
 }
 
 type ContentSize struct {
-	Number float64 `json:"number"`
-	String string  `json:"string"`
+	Number int64  `json:"number"`
+	String string `json:"string"`
 }
 
 type DocumentMeta struct {
@@ -58,6 +127,14 @@ type DocumentMeta struct {
 	CreatedAt   string      `json:"createdAt"`
 }
 
+// Version - returns the version of the document described by the meta
+func (meta *DocumentMeta) Version() DocumentVersion {
+	return DocumentVersion{
+		UpdatedAt:   meta.UpdatedAt,
+		ContentSize: meta.ContentSize.Number,
+	}
+}
+
 func DataToDocumentMeta(data map[string]interface{}) *DocumentMeta {
 
 	d := &DocumentMeta{
@@ -66,7 +143,7 @@ func DataToDocumentMeta(data map[string]interface{}) *DocumentMeta {
 		Project: data["project"].(string),
 		Name:    data["name"].(string),
 		ContentSize: ContentSize{
-			Number: data["contentSize"].(map[string]interface{})["number"].(float64),
+			Number: dataToInt64(data["contentSize"].(map[string]interface{})["number"]),
 			String: data["contentSize"].(map[string]interface{})["string"].(string),
 		},
 		CreatedAt: data["createdAt"].(string),
@@ -88,3 +165,135 @@ type UpdatedDocument struct {
 type DeletedDocument struct {
 	Deleted bool `json:"deleted"`
 }
+
+// ProjectStats - number of documents and folders in a project
+type ProjectStats struct {
+	Documents int64 `json:"documents"`
+	Folders   int64 `json:"folders"`
+}
+
+// DataToProjectStats - converts project stats returned by the server
+func DataToProjectStats(data map[string]interface{}) *ProjectStats {
+	return &ProjectStats{
+		Documents: dataToInt64(data["documents"]),
+		Folders:   dataToInt64(data["folders"]),
+	}
+}
+
+type Project struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Public      bool   `json:"public"`
+	CreatedAt   string `json:"createdAt"`
+	UpdatedAt   string `json:"updatedAt"`
+	// optional fields
+	Stats *ProjectStats `json:"stats,omitempty"`
+}
+
+// DataToProject - converts a project returned by the server
+func DataToProject(data map[string]interface{}) *Project {
+	p := &Project{
+		Id:        data["id"].(string),
+		Name:      data["name"].(string),
+		CreatedAt: data["createdAt"].(string),
+		UpdatedAt: data["updatedAt"].(string),
+	}
+
+	if description, ok := data["description"].(string); ok {
+		p.Description = description
+	}
+
+	if public, ok := data["public"].(bool); ok {
+		p.Public = public
+	}
+
+	if stats, ok := data["stats"].(map[string]interface{}); ok {
+		p.Stats = DataToProjectStats(stats)
+	}
+
+	return p
+}
+
+// NewProject extends Project
+type NewProject struct {
+	Project `json:",inline"`
+	Exists  bool `json:"exists"`
+}
+
+type DeletedProject struct {
+	Deleted bool `json:"deleted"`
+}
+
+// Manifest - documents and folders of an exported project
+type Manifest struct {
+	Project   string             `json:"project"`
+	Documents []ManifestDocument `json:"documents"`
+	Folders   []Folder           `json:"folders"`
+}
+
+// ManifestDocument - a document of a manifest with the hash of its content
+type ManifestDocument struct {
+	DocumentMeta
+	ContentHash string `json:"contentHash"`
+}
+
+// DeletedFolder - result of DeleteFolder
+type DeletedFolder struct {
+	Deleted   bool  `json:"deleted"`
+	Documents int64 `json:"documents"` // documents deleted inside the folder
+	Folders   int64 `json:"folders"`   // folders deleted inside the folder
+}
+
+// MovedDocument - result of RenameDocument and MoveDocument
+type MovedDocument struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Project string `json:"project"`
+	OldPath string `json:"oldPath"`
+	// moved by copying the document and deleting the original, the document has a new id
+	Copied bool `json:"copied"`
+}
+
+// MovedFolder - result of RenameFolder and MoveFolder
+type MovedFolder struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Project string `json:"project"`
+	OldPath string `json:"oldPath"`
+	// moved by copying the folder and deleting the original, the folder and its documents have new ids
+	Copied bool `json:"copied"`
+}
+
+// DocumentVersion - expected state of a document, used as precondition for updates
+// only fields that are set are compared
+type DocumentVersion struct {
+	UpdatedAt   string `json:"updatedAt,omitempty"`
+	ContentSize int64  `json:"contentSize,omitempty"`
+	ContentHash string `json:"contentHash,omitempty"` // sha256 of the content, see jsonbank.ContentHash
+}
+
+// SchemaViolation - a location in a document that does not match a json schema
+type SchemaViolation struct {
+	Path    string `json:"path"`    // json pointer to the invalid value, empty for the document root
+	Keyword string `json:"keyword"` // schema keyword that failed, e.g. type or required
+	Message string `json:"message"`
+}
+
+// Change - a difference between two json values
+type Change struct {
+	Op       string `json:"op"`   // add, remove or replace
+	Path     string `json:"path"` // json pointer to the changed value
+	OldValue any    `json:"oldValue,omitempty"`
+	NewValue any    `json:"newValue,omitempty"`
+}
+
+// JsonProblem - a problem found in json content, with its position
+type JsonProblem struct {
+	Line    int    `json:"line"`   // 1-based line
+	Column  int    `json:"column"` // 1-based column, in bytes
+	Offset  int64  `json:"offset"` // 0-based byte offset
+	Message string `json:"message"`
+}
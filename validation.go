@@ -0,0 +1,172 @@
+package jsonbank
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jsonbankio/go-sdk/types"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validation - strict rules checked on written content in addition to json syntax
+// zero values disable a rule
+type Validation struct {
+	DisallowDuplicateKeys bool // Reject objects that contain the same key more than once
+	MaxDepth              int  // Maximum nesting of objects and arrays
+	MaxSize               int  // Maximum content size in bytes
+	MaxStringLength       int  // Maximum length of strings and keys in characters
+}
+
+// enabled - checks if any rule is set
+func (rules Validation) enabled() bool {
+	return rules != Validation{}
+}
+
+// CheckJson - checks that content is valid json and satisfies the rules
+// returns the first problem found with its line and column, or nil
+func CheckJson(content []byte, rules Validation) *types.JsonProblem {
+	problem := func(offset int64, format string, args ...any) *types.JsonProblem {
+		line, column := position(content, offset)
+		return &types.JsonProblem{Line: line, Column: column, Offset: offset, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if rules.MaxSize > 0 && len(content) > rules.MaxSize {
+		return problem(int64(rules.MaxSize), "content is larger than %v bytes", rules.MaxSize)
+	}
+
+	// syntax errors point at the offending character
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, content); err != nil {
+		var syntaxErr *json.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			return problem(0, "%v", err.Error())
+		}
+
+		offset := syntaxErr.Offset
+		if offset > 0 && offset <= int64(len(content)) && strings.HasPrefix(err.Error(), "invalid character") {
+			offset--
+		}
+		return problem(offset, "%v", err.Error())
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	// containers being read, each object keeps its keys and whether the next string is a key
+	type container struct {
+		object    bool
+		keys      map[string]bool
+		expectKey bool
+	}
+	var stack []*container
+
+	for {
+		start := skipSeparators(content, decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return problem(start, "%v", err.Error())
+		}
+
+		var parent *container
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+
+		isKey := parent != nil && parent.object && parent.expectKey
+		if parent != nil && parent.object {
+			parent.expectKey = !parent.expectKey
+		}
+
+		switch value := token.(type) {
+		case json.Delim:
+			switch value {
+			case '{', '[':
+				stack = append(stack, &container{object: value == '{', keys: map[string]bool{}, expectKey: true})
+				if rules.MaxDepth > 0 && len(stack) > rules.MaxDepth {
+					return problem(start, "content is nested deeper than %v levels", rules.MaxDepth)
+				}
+			case '}', ']':
+				stack = stack[:len(stack)-1]
+			}
+		case string:
+			if rules.MaxStringLength > 0 && utf8.RuneCountInString(value) > rules.MaxStringLength {
+				return problem(start, "string is longer than %v characters", rules.MaxStringLength)
+			}
+
+			if isKey && rules.DisallowDuplicateKeys {
+				if parent.keys[value] {
+					return problem(start, "duplicate key %q", value)
+				}
+				parent.keys[value] = true
+			}
+		}
+	}
+}
+
+// skipSeparators - moves an offset past whitespace, commas and colons to the start of the next token
+func skipSeparators(content []byte, offset int64) int64 {
+	for offset < int64(len(content)) {
+		switch content[offset] {
+		case ' ', '\t', '\n', '\r', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// position - converts a byte offset to a 1-based line and column
+func position(content []byte, offset int64) (int, int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+
+	return line, column
+}
+
+// validateContent - checks that content is valid json and satisfies the configured validation rules
+func (jsb *Instance) validateContent(content []byte) *RequestError {
+	rules := jsb.config.Validation
+	if !rules.enabled() {
+		if !json.Valid(content) {
+			return &InvalidJsonError
+		}
+		return nil
+	}
+
+	if problem := CheckJson(content, rules); problem != nil {
+		code := "json_validation"
+		if !json.Valid(content) {
+			code = InvalidJsonError.Code
+		}
+
+		return &RequestError{code, fmt.Sprintf("line %v, column %v: %v", problem.Line, problem.Column, problem.Message)}
+	}
+
+	return nil
+}
+
+// validateDocumentSchemas - checks content against the schemas attached to a document path
+func (jsb *Instance) validateDocumentSchemas(documentPath string, content []byte) *RequestError {
+	violations, err := jsb.validateSchemas(documentPath, content)
+	if err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return schemaError(violations)
+	}
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package jsonbank
+
+import (
+	"context"
+	"github.com/jsonbankio/go-sdk/types"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// SkipDir - returned by a WalkFunc to skip the folder being visited, or the remaining entries of the folder
+// containing a document. It is the same value as fs.SkipDir.
+var SkipDir = fs.SkipDir
+
+// WalkFunc - called by Walk for every folder and document
+// err is set when the entry or the listing of a folder could not be fetched, returning nil then continues the walk
+type WalkFunc func(entry *types.WalkEntry, err *RequestError) error
+
+// WalkOptions - options of WalkWithOptions
+type WalkOptions struct {
+	Concurrency int  // Maximum number of concurrent requests, 4 by default
+	LoadContent bool // Load the content of documents into WalkEntry.Content
+}
+
+// Walk - visits every folder and document of a project or folder in lexical order, in the style of
+// filepath.WalkDir. path is a project name or a folder path, the root is visited first.
+func (jsb *Instance) Walk(ctx context.Context, path string, fn WalkFunc) error {
+	return jsb.WalkWithOptions(ctx, path, WalkOptions{}, fn)
+}
+
+// WalkWithOptions - Walk with options, children of folders are fetched concurrently
+// while fn is always called from a single goroutine. Requests for entries that are skipped or
+// not reached because fn returned an error are not sent.
+func (jsb *Instance) WalkWithOptions(ctx context.Context, path string, options WalkOptions, fn WalkFunc) error {
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+
+	// requests started ahead of fn stop when the walk returns
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := &walker{jsb: jsb, ctx: ctx, options: options, fn: fn, slots: make(chan struct{}, options.Concurrency)}
+	path = strings.Trim(path, "/")
+
+	root := &types.WalkEntry{Path: path}
+	if strings.Contains(path, "/") {
+		folder, err := jsb.GetFolder(path)
+		if err != nil {
+			return w.skip(fn(root, err))
+		}
+		root.Folder = folder
+	}
+
+	return w.skip(w.visitFolder(root, w.list(root)))
+}
+
+// walker - state of a walk
+type walker struct {
+	jsb     *Instance
+	ctx     context.Context
+	options WalkOptions
+	fn      WalkFunc
+	slots   chan struct{} // limits concurrent requests
+}
+
+// walkResult - the result of a request made in the background
+type walkResult struct {
+	done     chan struct{}
+	cancel   context.CancelFunc // stops the request unless it already started
+	contents *types.FolderContents
+	content  any
+	err      *RequestError
+}
+
+// wait - waits for the result unless the walk is cancelled
+func (w *walker) wait(result *walkResult) error {
+	select {
+	case <-result.done:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
+// background - runs a request once a slot is free, unless the walk or the result is cancelled before
+func (w *walker) background(request func(result *walkResult)) *walkResult {
+	ctx, cancel := context.WithCancel(w.ctx)
+	result := &walkResult{done: make(chan struct{}), cancel: cancel}
+
+	go func() {
+		defer close(result.done)
+		defer cancel()
+
+		select {
+		case w.slots <- struct{}{}:
+			defer func() { <-w.slots }()
+		case <-ctx.Done():
+			result.err = &RequestError{"cancelled", ctx.Err().Error()}
+			return
+		}
+
+		if err := ctx.Err(); err != nil {
+			result.err = &RequestError{"cancelled", err.Error()}
+			return
+		}
+
+		request(result)
+	}()
+
+	return result
+}
+
+// list - lists a folder or project root in the background
+func (w *walker) list(entry *types.WalkEntry) *walkResult {
+	return w.background(func(result *walkResult) {
+		if entry.Folder == nil {
+			result.contents, result.err = w.jsb.ListProject(entry.Path)
+		} else {
+			result.contents, result.err = w.jsb.ListFolder(entry.Path)
+		}
+	})
+}
+
+// load - loads the content of a document in the background
+func (w *walker) load(entry *types.WalkEntry) *walkResult {
+	return w.background(func(result *walkResult) {
+		result.content, result.err = w.jsb.GetOwnContent(entry.Path)
+	})
+}
+
+// visitFolder - visits a folder and its children
+func (w *walker) visitFolder(entry *types.WalkEntry, listing *walkResult) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := w.fn(entry, nil); err != nil {
+		// the folder is skipped, its listing is not needed
+		listing.cancel()
+		return err
+	}
+
+	if err := w.wait(listing); err != nil {
+		return err
+	}
+
+	if listing.err != nil {
+		return w.fn(entry, listing.err)
+	}
+
+	// children sorted by name, requests for them start right away
+	type child struct {
+		name   string
+		entry  *types.WalkEntry
+		result *walkResult
+	}
+
+	var children []child
+	// children that are not visited do not need their requests
+	defer func() {
+		for _, c := range children {
+			if c.result != nil {
+				c.result.cancel()
+			}
+		}
+	}()
+
+	for i := range listing.contents.Folders {
+		folder := &listing.contents.Folders[i]
+		e := &types.WalkEntry{Path: folder.Project + "/" + folder.Path, Folder: folder}
+		children = append(children, child{folder.Name, e, w.list(e)})
+	}
+
+	for i := range listing.contents.Documents {
+		document := &listing.contents.Documents[i]
+		e := &types.WalkEntry{Path: document.Project + "/" + document.Path, Document: document}
+
+		var result *walkResult
+		if w.options.LoadContent {
+			result = w.load(e)
+		}
+		children = append(children, child{document.Name, e, result})
+	}
+
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].name < children[j].name
+	})
+
+	for _, c := range children {
+		var err error
+		if c.entry.IsFolder() {
+			err = w.skip(w.visitFolder(c.entry, c.result))
+		} else {
+			err = w.visitDocument(c.entry, c.result)
+		}
+
+		if err == SkipDir {
+			// a document skips the rest of its folder
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// visitDocument - visits a document, waiting for its content when loaded
+func (w *walker) visitDocument(entry *types.WalkEntry, content *walkResult) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	if content != nil {
+		if err := w.wait(content); err != nil {
+			return err
+		}
+
+		if content.err != nil {
+			return w.fn(entry, content.err)
+		}
+
+		entry.Content = content.content
+	}
+
+	return w.fn(entry, nil)
+}
+
+// skip - ignores SkipDir returned for a folder
+func (w *walker) skip(err error) error {
+	if err == SkipDir {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,282 @@
+package jsonbank
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strconv"
+)
+
+// yamlToJson - converts a yaml document to json, keys keep their order
+func yamlToJson(content []byte) ([]byte, *RequestError) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	var document yaml.Node
+	if err := decoder.Decode(&document); err != nil {
+		if err == io.EOF {
+			return nil, &RequestError{"invalid_yaml", "Content is empty"}
+		}
+		return nil, &RequestError{"invalid_yaml", err.Error()}
+	}
+
+	var next yaml.Node
+	if err := decoder.Decode(&next); err != io.EOF {
+		return nil, &RequestError{"invalid_yaml", "Multiple yaml documents are not supported"}
+	}
+
+	var buf bytes.Buffer
+	budget := yamlBudget(maxYamlNodes)
+	if err := writeYamlNode(&buf, &document, &budget); err != nil {
+		if err == errYamlExpansion {
+			return nil, &RequestError{"yaml_too_large", err.Error()}
+		}
+		return nil, &RequestError{"invalid_yaml", err.Error()}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// maxYamlNodes - the number of values a yaml document may expand to, aliases can repeat a value any number of times
+const maxYamlNodes = 1000000
+
+// errYamlExpansion - returned once a yaml document expands to more than maxYamlNodes values
+var errYamlExpansion = errors.New("Aliases expand to too many values")
+
+// yamlBudget - the number of values left to write
+type yamlBudget int
+
+// spend - counts a value, fails once the budget is used up
+func (b *yamlBudget) spend() error {
+	if *b <= 0 {
+		return errYamlExpansion
+	}
+	*b--
+	return nil
+}
+
+// writeYamlNode - writes a yaml node as json, every value written is counted against the budget
+func writeYamlNode(buf *bytes.Buffer, node *yaml.Node, budget *yamlBudget) error {
+	if err := budget.spend(); err != nil {
+		return err
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeYamlNode(buf, node.Content[0], budget)
+	case yaml.AliasNode:
+		return writeYamlNode(buf, node.Alias, budget)
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeYamlNode(buf, item, budget); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case yaml.MappingNode:
+		pairs, err := yamlPairs(node, budget)
+		if err != nil {
+			return err
+		}
+
+		buf.WriteByte('{')
+		for i, pair := range pairs {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, pair.key)
+			buf.WriteByte(':')
+			if err := writeYamlNode(buf, pair.value, budget); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+
+	return writeYamlScalar(buf, node)
+}
+
+// yamlPair - a key and value of a yaml mapping
+type yamlPair struct {
+	key   string
+	value *yaml.Node
+}
+
+// yamlPairs - returns the pairs of a mapping in order, merge keys (<<) are expanded where they appear
+// keys of the mapping itself take precedence over merged keys, and later keys replace earlier ones like in a json object
+// merged pairs are counted against the budget as merges of merges can repeat a mapping any number of times
+func yamlPairs(node *yaml.Node, budget *yamlBudget) ([]yamlPair, error) {
+	var pairs []yamlPair
+	index := map[string]int{}
+
+	set := func(key string, value *yaml.Node, override bool) {
+		if i, ok := index[key]; ok {
+			if override {
+				pairs[i].value = value
+			}
+			return
+		}
+		index[key] = len(pairs)
+		pairs = append(pairs, yamlPair{key, value})
+	}
+
+	// keys of the mapping itself, merged keys with the same name are left out
+	explicit := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if keyNode := node.Content[i]; keyNode.Tag != "!!merge" {
+			if key := resolveAlias(keyNode); key.Kind == yaml.ScalarNode {
+				explicit[key.Value] = true
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, value := node.Content[i], node.Content[i+1]
+
+		if keyNode.Tag == "!!merge" {
+			sources := []*yaml.Node{value}
+			if resolved := resolveAlias(value); resolved.Kind == yaml.SequenceNode {
+				sources = resolved.Content
+			}
+
+			for _, source := range sources {
+				source = resolveAlias(source)
+				if source.Kind != yaml.MappingNode {
+					return nil, fmt.Errorf("line %v: merge value must be a mapping", keyNode.Line)
+				}
+
+				sourcePairs, err := yamlPairs(source, budget)
+				if err != nil {
+					return nil, err
+				}
+
+				// earlier sources take precedence over later ones
+				for _, pair := range sourcePairs {
+					if err := budget.spend(); err != nil {
+						return nil, err
+					}
+					if !explicit[pair.key] {
+						set(pair.key, pair.value, false)
+					}
+				}
+			}
+			continue
+		}
+
+		key := resolveAlias(keyNode)
+		if key.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %v: keys must be scalar values", keyNode.Line)
+		}
+		set(key.Value, value, true)
+	}
+
+	return pairs, nil
+}
+
+// resolveAlias - returns the node an alias points to
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// writeYamlScalar - writes a yaml scalar as json, numbers keep their text when it is valid json
+func writeYamlScalar(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.ShortTag() {
+	case "!!str", "!!binary":
+		writeCanonicalString(buf, node.Value)
+		return nil
+	case "!!timestamp":
+		// dates are kept as written
+		writeCanonicalString(buf, node.Value)
+		return nil
+	case "!!int", "!!float":
+		if isJsonNumber(node.Value) {
+			buf.WriteString(node.Value)
+			return nil
+		}
+	}
+
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("line %v: %v cannot be represented in json", node.Line, node.Value)
+	}
+
+	buf.Write(data)
+	return nil
+}
+
+// isJsonNumber - checks if a text is a json number
+func isJsonNumber(s string) bool {
+	if s == "" || !(s[0] == '-' || (s[0] >= '0' && s[0] <= '9')) {
+		return false
+	}
+
+	var number json.Number
+	return json.Unmarshal([]byte(s), &number) == nil
+}
+
+// jsonToYaml - converts json content to yaml
+func jsonToYaml(content []byte) ([]byte, *RequestError) {
+	value, err := decodeJson(content)
+	if err != nil {
+		return nil, err
+	}
+
+	data, yamlErr := yaml.Marshal(yamlNode(value))
+	if yamlErr != nil {
+		return nil, &RequestError{"yaml_error", yamlErr.Error()}
+	}
+
+	return data, nil
+}
+
+// yamlNode - builds the yaml node of a decoded json value, object keys are sorted
+func yamlNode(value any) *yaml.Node {
+	switch v := value.(type) {
+	case map[string]any:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		if len(v) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		for _, key := range sortedKeys(v) {
+			node.Content = append(node.Content, yamlNode(key), yamlNode(v[key]))
+		}
+		return node
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		if len(v) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		for _, item := range v {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	case json.Number:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: strconv.FormatBool(v)}
+	}
+
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: "null"}
+}